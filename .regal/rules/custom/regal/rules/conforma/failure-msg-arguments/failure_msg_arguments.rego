@@ -0,0 +1,54 @@
+# METADATA
+# description: Number of failure_msg placeholders must match the arguments passed to lib.result_helper
+# related_resources:
+# - description: documentation
+#   ref: https://conforma.dev/docs/policy/authoring.html#_linting
+# schemas:
+# - input: schema.regal.ast
+package custom.regal.rules.conforma["failure-msg-arguments"]
+
+import rego.v1
+
+import data.regal.ast
+import data.regal.result
+
+report contains violation if {
+	some rule in ast.rules
+
+	rule.head.ref[0].value in {"deny", "warn"}
+
+	some annotation in rule.annotations
+	msg := annotation.custom.failure_msg
+	is_string(msg)
+
+	# explicit argument indexes, e.g. %[1]s, can use the same argument more
+	# than once so the placeholders can't simply be counted
+	not regex.match(`%[-+# 0]*\[`, msg)
+
+	walk(rule, [_, node])
+
+	node.type == "call"
+	regex.match(`^(data\.)?lib\.result_helper(_[a-z_]+)?$`, ast.ref_to_string(node.value[0].value))
+
+	# only literal arrays can be counted
+	node.value[2].type == "array"
+
+	placeholders := count(_placeholders(msg))
+	arguments := count(node.value[2].value)
+
+	placeholders != arguments
+
+	violation := result.fail(rego.metadata.chain(), object.union(
+		result.location(node.value[0]),
+		{"description": sprintf(
+			"Failure message has %d placeholder(s) but %d argument(s) are passed to %s",
+			[placeholders, arguments, ast.ref_to_string(node.value[0].value)],
+		)},
+	))
+}
+
+_placeholders(msg) := regex.find_n(
+	`%[-+# 0]*([0-9]+|\*)?(\.([0-9]+|\*)?)?[a-zA-Z]`,
+	replace(msg, "%%", ""),
+	-1,
+)
@@ -0,0 +1,108 @@
+package custom.regal.rules.conforma["failure-msg-arguments_test"]
+
+import rego.v1
+
+import data.custom.regal.rules.conforma["failure-msg-arguments"] as rule
+
+test_fail_too_few_arguments if {
+	r := rule.report with input as regal.parse_module("p.rego", `package p
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Bad image
+# custom:
+#   short_name: bad_image
+#   failure_msg: Image %q is bad, found %d problem(s)
+deny contains result if {
+	some image in input.images
+	result := lib.result_helper(rego.metadata.chain(), [image])
+}
+`)
+
+	r == {{
+		"category": "conforma",
+		"description": "Failure message has 2 placeholder(s) but 1 argument(s) are passed to lib.result_helper",
+		"level": "error",
+		"location": {
+			"col": 12,
+			"end": {"col": 29, "row": 14},
+			"file": "p.rego",
+			"row": 14,
+			"text": "\tresult := lib.result_helper(rego.metadata.chain(), [image])",
+		},
+		"related_resources": [{
+			"description": "documentation",
+			"ref": "https://conforma.dev/docs/policy/authoring.html#_linting",
+		}],
+		"title": "failure-msg-arguments",
+	}}
+}
+
+test_fail_too_many_arguments if {
+	r := rule.report with input as regal.parse_module("p.rego", `package p
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Always
+# custom:
+#   short_name: always
+#   failure_msg: Always fails
+warn contains result if {
+	result := lib.result_helper_with_term(rego.metadata.chain(), ["extra"], "term")
+}
+`)
+
+	count(r) == 1
+	some violation in r
+	violation.description == concat(" ", [
+		"Failure message has 0 placeholder(s) but 1 argument(s)",
+		"are passed to lib.result_helper_with_term",
+	])
+}
+
+test_success_matching_arguments if {
+	r := rule.report with input as regal.parse_module("p.rego", `package p
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Bad image
+# custom:
+#   short_name: bad_image
+#   failure_msg: Image %q is 100%% bad, found %d problem(s) in %.2f seconds
+deny contains result if {
+	some image in input.images
+	result := lib.result_helper(rego.metadata.chain(), [image, count(image.problems), image.seconds])
+}
+
+# METADATA
+# title: Indexed
+# custom:
+#   short_name: indexed
+#   failure_msg: Image %[1]s is bad, really %[1]s is bad
+deny contains result if {
+	some image in input.images
+	result := lib.result_helper(rego.metadata.chain(), [image])
+}
+
+# METADATA
+# title: Computed
+# custom:
+#   short_name: computed
+#   failure_msg: "%s: %s"
+deny contains result if {
+	some params in input.params
+	result := lib.result_helper(rego.metadata.chain(), params)
+}
+`)
+
+	r == set()
+}
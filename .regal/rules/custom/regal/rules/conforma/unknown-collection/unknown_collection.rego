@@ -0,0 +1,100 @@
+# METADATA
+# description: Collections listed by a policy rule must be defined under the collection directory of its policy kind, e.g. policy/release/collection
+# related_resources:
+# - description: documentation
+#   ref: https://conforma.dev/docs/policy/authoring.html#_linting
+# schemas:
+# - input: schema.regal.ast
+package custom.regal.rules.conforma["unknown-collection"]
+
+import rego.v1
+
+import data.regal.ast
+import data.regal.result
+
+# METADATA
+# description: collects the collections defined, and the collections used, in each module
+aggregate contains entry if {
+	count(_defined) + count(_used) > 0
+
+	entry := result.aggregate(rego.metadata.chain(), {
+		"defined": _defined,
+		"used": _used,
+	})
+}
+
+# METADATA
+# description: >-
+#   reports the collections used that are not defined in any of the modules of
+#   the same policy kind
+# schemas:
+# - input: schema.regal.aggregate
+aggregate_report contains violation if {
+	defined := {collection |
+		some entry in input.aggregate
+		some collection in entry.aggregate_data.defined
+	}
+
+	# when linting only some of the files none of the collections might be
+	# known, reporting all the collections used would not be helpful then
+	count(defined) > 0
+
+	some entry in input.aggregate
+	some used in entry.aggregate_data.used
+
+	not {"kind": used.kind, "name": used.name} in defined
+
+	violation := result.fail(rego.metadata.chain(), object.union(
+		result.location(used),
+		{"description": sprintf("Collection %q is not defined under policy/%s/collection", [used.name, used.kind])},
+	))
+}
+
+# A collection is defined by an otherwise empty package named after it, e.g.
+# collection.minimal, its title is the name rules use to refer to it. The
+# collections of a policy kind are defined in its directory, e.g. the
+# collections of the release policy are under policy/release/collection.
+_defined contains {"kind": _kind, "name": name} if {
+	_collection_package
+
+	some annotation in input["package"].annotations
+	annotation.scope == "package"
+
+	name := annotation.title
+}
+
+_defined contains {"kind": _kind, "name": ast.package_path[count(ast.package_path) - 1]} if _collection_package
+
+_collection_package if ast.package_path[count(ast.package_path) - 2] == "collection"
+
+_used contains used if {
+	some rule in ast.rules
+	some annotation in rule.annotations
+	some name in annotation.custom.collections
+
+	used := object.union({"kind": _kind, "name": name}, _entry_location(annotation, name))
+}
+
+# the policy kind of the module, i.e. the directory within policy/ the module
+# is in, e.g. release
+_kind := kind if {
+	[match] := regex.find_all_string_submatch_n(`(?:^|/)policy/([^/]+)/`, input.regal.file.name, 1)
+	kind := match[1]
+} else := ""
+
+# points at the entry of the collection within the METADATA block, or at the
+# whole METADATA block if the entry can't be found
+_entry_location(annotation, name) := result.location({"location": location}) if {
+	[start, _, end, _] := split(annotation.location, ":")
+
+	rows := [row |
+		some row in numbers.range(to_number(start), to_number(end))
+		_list_item(input.regal.file.lines[row - 1]) == name
+	]
+
+	line := input.regal.file.lines[rows[0] - 1]
+	location := sprintf("%d:1:%d:%d", [rows[0], rows[0], count(line) + 1])
+} else := result.location(annotation)
+
+# the value of a YAML list item in a comment, e.g. `#   - minimal`
+_list_item(line) := trim(trim_space(trim_prefix(trim_space(trim_prefix(line, "#")), "- ")), `"'`)
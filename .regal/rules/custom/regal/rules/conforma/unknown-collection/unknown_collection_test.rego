@@ -0,0 +1,144 @@
+package custom.regal.rules.conforma["unknown-collection_test"]
+
+import rego.v1
+
+import data.custom.regal.rules.conforma["unknown-collection"] as rule
+
+_collection := regal.parse_module("policy/release/collection/minimal/minimal.rego", `#
+# METADATA
+# title: minimal
+# description: A minimal set of rules.
+package collection.minimal
+
+import rego.v1
+`)
+
+_policy := regal.parse_module("policy/release/p/p.rego", `package p
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Always
+# custom:
+#   short_name: always
+#   failure_msg: Always fails
+#   collections:
+#   - minimal
+#   - "maximal"
+deny contains result if {
+	result := lib.result_helper(rego.metadata.chain(), [])
+}
+`)
+
+test_fail_unknown_collection if {
+	agg1 := rule.aggregate with input as _collection
+	agg2 := rule.aggregate with input as _policy
+	r := rule.aggregate_report with input as {"aggregate": (agg1 | agg2)}
+
+	r == {{
+		"category": "conforma",
+		"description": "Collection \"maximal\" is not defined under policy/release/collection",
+		"level": "error",
+		"location": {
+			"col": 1,
+			"end": {"col": 16, "row": 14},
+			"file": "policy/release/p/p.rego",
+			"row": 14,
+			"text": `#   - "maximal"`,
+		},
+		"related_resources": [{
+			"description": "documentation",
+			"ref": "https://conforma.dev/docs/policy/authoring.html#_linting",
+		}],
+		"title": "unknown-collection",
+	}}
+}
+
+test_success_known_collections if {
+	agg1 := rule.aggregate with input as _collection
+	agg2 := rule.aggregate with input as regal.parse_module("policy/release/p/p.rego", `package p
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Always
+# custom:
+#   short_name: always
+#   failure_msg: Always fails
+#   collections:
+#   - minimal
+deny contains result if {
+	result := lib.result_helper(rego.metadata.chain(), [])
+}
+`)
+	r := rule.aggregate_report with input as {"aggregate": (agg1 | agg2)}
+
+	r == set()
+}
+
+test_success_no_collections_linted if {
+	agg := rule.aggregate with input as _policy
+	r := rule.aggregate_report with input as {"aggregate": agg}
+
+	r == set()
+}
+
+test_fail_collection_of_another_kind if {
+	agg1 := rule.aggregate with input as _collection
+	agg2 := rule.aggregate with input as regal.parse_module("policy/task/p/p.rego", `package p
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Always
+# custom:
+#   short_name: always
+#   failure_msg: Always fails
+#   collections:
+#   - minimal
+deny contains result if {
+	result := lib.result_helper(rego.metadata.chain(), [])
+}
+`)
+	r := rule.aggregate_report with input as {"aggregate": (agg1 | agg2)}
+
+	{v.description | some v in r} == {"Collection \"minimal\" is not defined under policy/task/collection"}
+}
+
+test_success_collection_of_the_same_kind if {
+	agg1 := rule.aggregate with input as _collection
+	agg2 := rule.aggregate with input as regal.parse_module("policy/task/collection/minimal/minimal.rego", `#
+# METADATA
+# title: minimal
+# description: A minimal set of task rules.
+package collection.minimal
+
+import rego.v1
+`)
+	agg3 := rule.aggregate with input as regal.parse_module("policy/task/p/p.rego", `package p
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Always
+# custom:
+#   short_name: always
+#   failure_msg: Always fails
+#   collections:
+#   - minimal
+deny contains result if {
+	result := lib.result_helper(rego.metadata.chain(), [])
+}
+`)
+	r := rule.aggregate_report with input as {"aggregate": agg1 | agg2 | agg3}
+
+	r == set()
+}
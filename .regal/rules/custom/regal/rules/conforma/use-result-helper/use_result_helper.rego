@@ -0,0 +1,51 @@
+# METADATA
+# description: Policy rules must build their results using lib.result_helper
+# related_resources:
+# - description: documentation
+#   ref: https://conforma.dev/docs/policy/authoring.html#_linting
+# schemas:
+# - input: schema.regal.ast
+package custom.regal.rules.conforma["use-result-helper"]
+
+import rego.v1
+
+import data.regal.ast
+import data.regal.result
+
+# The result helper sets the code, the message and the effective_on date of
+# the result from the rule's annotations, a deny or warn rule producing its
+# result in any other way will not be reported correctly.
+report contains violation if {
+	some rule in ast.rules
+
+	rule.head.ref[0].value in {"deny", "warn"}
+	rule.annotations
+
+	not _uses_result_helper(rule)
+
+	violation := result.fail(rego.metadata.chain(), result.location(rule.head))
+}
+
+_uses_result_helper(rule) if {
+	some name in _calls(rule)
+	_is_result_helper(name)
+}
+
+# a function defined in the same package building the result
+_uses_result_helper(rule) if {
+	some name in _calls(rule)
+	some function in ast.functions
+
+	ast.ref_to_string(function.head.ref) == name
+
+	some called in _calls(function)
+	_is_result_helper(called)
+}
+
+_calls(rule) := {ast.ref_to_string(node.value[0].value) |
+	walk(rule, [_, node])
+
+	node.type == "call"
+}
+
+_is_result_helper(name) if regex.match(`^(data\.)?lib\.result_helper(_[a-z_]+)?$`, name)
@@ -0,0 +1,104 @@
+package custom.regal.rules.conforma["use-result-helper_test"]
+
+import rego.v1
+
+import data.custom.regal.rules.conforma["use-result-helper"] as rule
+
+test_fail_result_not_from_helper if {
+	r := rule.report with input as regal.parse_module("p.rego", `package p
+
+import rego.v1
+
+# METADATA
+# title: Always
+# custom:
+#   short_name: always
+#   failure_msg: Always fails
+deny contains result if {
+	result := {"code": "p.always", "msg": "Always fails"}
+}
+`)
+
+	r == {{
+		"category": "conforma",
+		"description": "Policy rules must build their results using lib.result_helper",
+		"level": "error",
+		"location": {
+			"col": 1,
+			"end": {"col": 21, "row": 10},
+			"file": "p.rego",
+			"row": 10,
+			"text": "deny contains result if {",
+		},
+		"related_resources": [{
+			"description": "documentation",
+			"ref": "https://conforma.dev/docs/policy/authoring.html#_linting",
+		}],
+		"title": "use-result-helper",
+	}}
+}
+
+test_success_result_helper if {
+	r := rule.report with input as regal.parse_module("p.rego", `package p
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Always
+# custom:
+#   short_name: always
+#   failure_msg: Always fails
+deny contains result if {
+	result := lib.result_helper(rego.metadata.chain(), [])
+}
+
+# METADATA
+# title: Term
+# custom:
+#   short_name: term
+#   failure_msg: Fails for %s
+warn contains result if {
+	some term in input.terms
+	result := object.union(lib.result_helper_with_term(rego.metadata.chain(), [term], term), {})
+}
+`)
+
+	r == set()
+}
+
+test_success_result_helper_in_function if {
+	r := rule.report with input as regal.parse_module("p.rego", `package p
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Always
+# custom:
+#   short_name: always
+#   failure_msg: Always fails
+deny contains _result(rego.metadata.chain())
+
+_result(chain) := lib.result_helper(chain, [])
+`)
+
+	r == set()
+}
+
+test_success_not_a_policy_rule if {
+	r := rule.report with input as regal.parse_module("p.rego", `package p
+
+import rego.v1
+
+deny contains {"msg": "not annotated"} if {
+	input.fail
+}
+
+allow contains {"msg": "not a deny or warn rule"}
+`)
+
+	r == set()
+}
@@ -22,26 +22,40 @@ import (
 	_ "embed"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
 	"path"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"slices"
+	"sort"
 	"strings"
 	"testing"
 
 	"github.com/cucumber/godog"
-	// Neded so the "go run" command can execute.
+	"github.com/pmezard/go-difflib/difflib"
+	// Needed so the "go build" command in TestMain can execute.
 	_ "github.com/conforma/cli/cmd"
 )
 
 const (
 	policyInputFilename  = "input.json"
 	policyConfigFilename = "policy.json"
+	// cliPath is the path, relative to the acceptance module, where the ec
+	// CLI is built once and reused by all scenarios
+	cliPath = "bin/ec"
+	// goldenDir is the directory, within the acceptance module, holding the
+	// normalized reports the scenarios' reports are compared to
+	goldenDir = "golden"
 )
 
+var updateGolden = flag.Bool("update", false, "write the golden reports from the reports of the scenarios instead of comparing them")
+
+var concurrency = flag.Int("concurrency", runtime.NumCPU(), "number of scenarios to run in parallel, with 1 the steps of each scenario are reported as they run")
+
 var (
 	//go:embed samples/policy-input-golden-container.json
 	sampleGCPolicyInput string
@@ -56,13 +70,14 @@ type testState struct {
 	tempDir              string
 	variables            map[string]string
 	report               report
+	reportJSON           []byte
 	cliPath              string
 	inputFileName        string
 	configFileName       string
 	acceptanceModulePath string
 }
 
-// Types used for parsing violations and warnings from report
+// Types used for parsing violations, warnings and successes from report
 type (
 	metadata struct {
 		Code        string   `json:"code"`
@@ -80,6 +95,8 @@ type (
 	input struct {
 		Violations []result `json:"violations"`
 		Warnings   []result `json:"warnings"`
+		// Successes are reported only with --show-successes
+		Successes []result `json:"successes"`
 	}
 
 	report struct {
@@ -144,9 +161,7 @@ func validateInputWithPolicyConfig(ctx context.Context) (context.Context, error)
 	}
 
 	cmd := exec.Command(
-		"go",
-		"run",
-		"github.com/conforma/cli",
+		ts.cliPath,
 		"validate",
 		"input",
 		"--file",
@@ -155,8 +170,13 @@ func validateInputWithPolicyConfig(ctx context.Context) (context.Context, error)
 		ts.configFileName,
 		"--strict=false",
 		"--info",
+		"--show-successes",
 	)
-	cmd.Dir = ts.acceptanceModulePath
+	// Run within the scenario's own directory, and have ec create its
+	// working directories there, so scenarios running in parallel do not
+	// share any files
+	cmd.Dir = ts.tempDir
+	cmd.Env = append(os.Environ(), "TMPDIR="+ts.tempDir)
 
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -172,6 +192,7 @@ func validateInputWithPolicyConfig(ctx context.Context) (context.Context, error)
 		return ctx, fmt.Errorf("unmarshalling report: %w", err)
 	}
 	ts.report = r
+	ts.reportJSON = stdout.Bytes()
 
 	return setTestState(ctx, ts), nil
 }
@@ -274,6 +295,296 @@ func thereShouldBeNoWarningsWithPackageInTheResult(ctx context.Context, pkg stri
 	return nil
 }
 
+func thereShouldBeAResultWithCodeInTheResult(ctx context.Context, kind string, code string) error {
+	ts, err := getTestState(ctx)
+	if err != nil {
+		return fmt.Errorf("reading test state: %w", err)
+	}
+
+	results := ts.report.results(kind)
+	for _, r := range results {
+		if r.Metadata.Code == code {
+			return nil
+		}
+	}
+
+	return errors.New(prettifyResults(fmt.Sprintf("expected a %s with code %q, got:", kind, code), results))
+}
+
+func thereShouldBeAResultWithCodeAndTermInTheResult(ctx context.Context, kind string, code string, term string) error {
+	ts, err := getTestState(ctx)
+	if err != nil {
+		return fmt.Errorf("reading test state: %w", err)
+	}
+
+	results := ts.report.results(kind)
+	for _, r := range results {
+		if r.Metadata.Code == code && r.Metadata.Term == term {
+			return nil
+		}
+	}
+
+	return errors.New(prettifyResults(fmt.Sprintf("expected a %s with code %q and term %q, got:", kind, code, term), results))
+}
+
+func thereShouldBeAResultWithMessageMatchingInTheResult(ctx context.Context, kind string, expr string) error {
+	ts, err := getTestState(ctx)
+	if err != nil {
+		return fmt.Errorf("reading test state: %w", err)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("compiling the message expression %q: %w", expr, err)
+	}
+
+	results := ts.report.results(kind)
+	for _, r := range results {
+		if re.MatchString(r.Message) {
+			return nil
+		}
+	}
+
+	return errors.New(prettifyResults(fmt.Sprintf("expected a %s with message matching /%s/, got:", kind, expr), results))
+}
+
+func thereShouldBeExactlyResultsInTheResult(ctx context.Context, count int, kind string) error {
+	ts, err := getTestState(ctx)
+	if err != nil {
+		return fmt.Errorf("reading test state: %w", err)
+	}
+
+	results := ts.report.results(kind)
+	if len(results) != count {
+		return errors.New(prettifyResults(fmt.Sprintf("expected exactly %d %ss, got %d:", count, kind, len(results)), results))
+	}
+
+	return nil
+}
+
+func ruleShouldHaveSucceeded(ctx context.Context, code string) error {
+	ts, err := getTestState(ctx)
+	if err != nil {
+		return fmt.Errorf("reading test state: %w", err)
+	}
+
+	for _, success := range ts.report.results("success") {
+		if success.Metadata.Code == code {
+			return nil
+		}
+	}
+
+	for _, kind := range []string{"violation", "warning"} {
+		for _, r := range ts.report.results(kind) {
+			if r.Metadata.Code == code {
+				return errors.New(prettifyResults(fmt.Sprintf("expected rule %q to succeed, got the %s:", code, kind), []result{r}))
+			}
+		}
+	}
+
+	return fmt.Errorf("expected rule %q to succeed, it was not evaluated", code)
+}
+
+func theReportShouldMatchGoldenFile(ctx context.Context, name string) error {
+	ts, err := getTestState(ctx)
+	if err != nil {
+		return fmt.Errorf("reading test state: %w", err)
+	}
+
+	got, err := normalizeReport(ts.reportJSON, map[string]string{
+		ts.tempDir:              "$TMPDIR",
+		ts.variables["GITROOT"]: "$GITROOT",
+	})
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(ts.acceptanceModulePath, goldenDir, name+".json")
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			return fmt.Errorf("creating the golden directory: %w", err)
+		}
+
+		if err := os.WriteFile(file, got, 0o644); err != nil {
+			return fmt.Errorf("writing the golden file %s: %w", file, err)
+		}
+
+		return nil
+	}
+
+	want, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("golden file %s does not exist, run the acceptance tests with -update to create it", file)
+		}
+		return fmt.Errorf("reading the golden file %s: %w", file, err)
+	}
+
+	if bytes.Equal(want, got) {
+		return nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(want)),
+		B:        difflib.SplitLines(string(got)),
+		FromFile: path.Join(goldenDir, name+".json"),
+		ToFile:   "report",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("comparing the report to the golden file %s: %w", file, err)
+	}
+
+	return fmt.Errorf("the report does not match the golden file, run the acceptance tests with -update to accept the changes:\n%s", diff)
+}
+
+// volatileReportKeys are the keys of the report that differ between runs
+// regardless of the policy, and are removed when normalizing the report
+var volatileReportKeys = []string{"effective-time", "ec-version"}
+
+// resultKeys are the keys of the report holding lists of results, which are
+// sorted when normalizing the report
+var resultKeys = []string{"violations", "warnings", "successes"}
+
+// normalizeReport returns the ec JSON report without the keys that change
+// between runs, with the results sorted and with the given paths, e.g. of the
+// scenario's temporary directory, replaced by their placeholders
+func normalizeReport(data []byte, paths map[string]string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var r any
+	if err := dec.Decode(&r); err != nil {
+		return nil, fmt.Errorf("unmarshalling report: %w", err)
+	}
+
+	// the paths can also be reported with the symbolic links resolved, e.g.
+	// the temporary directory on macOS
+	replacements := make([]string, 0, len(paths)*4)
+	for p, placeholder := range paths {
+		if p == "" {
+			continue
+		}
+		replacements = append(replacements, p, placeholder)
+		if resolved, err := filepath.EvalSymlinks(p); err == nil && resolved != p {
+			replacements = append(replacements, resolved, placeholder)
+		}
+	}
+	replacer := strings.NewReplacer(replacements...)
+
+	r, err := normalizeValue(r, replacer)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return nil, fmt.Errorf("marshalling the normalized report: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func normalizeValue(v any, replacer *strings.Replacer) (any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, k := range volatileReportKeys {
+			delete(v, k)
+		}
+
+		for k, e := range v {
+			n, err := normalizeValue(e, replacer)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = n
+		}
+
+		for _, k := range resultKeys {
+			results, ok := v[k].([]any)
+			if !ok {
+				continue
+			}
+
+			if err := sortResults(results); err != nil {
+				return nil, err
+			}
+		}
+
+		return v, nil
+	case []any:
+		for i, e := range v {
+			n, err := normalizeValue(e, replacer)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = n
+		}
+
+		return v, nil
+	case string:
+		return replacer.Replace(v), nil
+	default:
+		return v, nil
+	}
+}
+
+// sortResults sorts the results by their code, term and message, and by
+// their whole content when those are the same
+func sortResults(results []any) error {
+	type keyed struct {
+		key    string
+		result any
+	}
+
+	sorted := make([]keyed, 0, len(results))
+	for _, r := range results {
+		b, err := json.Marshal(r)
+		if err != nil {
+			return fmt.Errorf("marshalling result: %w", err)
+		}
+
+		var res result
+		if err := json.Unmarshal(b, &res); err != nil {
+			return fmt.Errorf("unmarshalling result: %w", err)
+		}
+
+		key := strings.Join([]string{res.Metadata.Code, res.Metadata.Term, res.Message, string(b)}, "\x00")
+		sorted = append(sorted, keyed{key: key, result: r})
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].key < sorted[j].key
+	})
+
+	for i, k := range sorted {
+		results[i] = k.result
+	}
+
+	return nil
+}
+
+// results returns the violations, warnings or successes, given by kind, of
+// all the inputs in the report
+func (r report) results(kind string) []result {
+	results := make([]result, 0, 10)
+	for _, filepath := range r.FilePaths {
+		switch kind {
+		case "violation":
+			results = append(results, filepath.Violations...)
+		case "warning":
+			results = append(results, filepath.Warnings...)
+		case "success":
+			results = append(results, filepath.Successes...)
+		}
+	}
+
+	return results
+}
+
 func prettifyResults(msg string, results []result) string {
 	for _, violation := range results {
 		code := violation.Metadata.Code
@@ -308,7 +619,7 @@ func setupScenario(ctx context.Context, sc *godog.Scenario) (context.Context, er
 
 	ts := testState{
 		id:                   sc.Id,
-		cliPath:              filepath.Join(gitroot, "acceptance/bin/ec"),
+		cliPath:              filepath.Join(acceptanceModulePath, cliPath),
 		tempDir:              tempDir,
 		acceptanceModulePath: acceptanceModulePath,
 		inputFileName:        path.Join(tempDir, policyInputFilename),
@@ -355,18 +666,59 @@ func InitializeScenario(sc *godog.ScenarioContext) {
 	sc.Step(`^there should be no violations with "([^"]*)" package in the result$`, thereShouldBeNoViolationsWithPackageInTheResult)
 	sc.Step(`^there should be no violations with "([^"]*)" code and "([^"]*)" term in the result$`, thereShouldBeNoViolationsWithRuleAndTermInTheResult)
 	sc.Step(`^there should be no warnings with "([^"]*)" package in the result$`, thereShouldBeNoWarningsWithPackageInTheResult)
+	sc.Step(`^there should be an? (violation|warning) with "([^"]*)" code in the result$`, thereShouldBeAResultWithCodeInTheResult)
+	sc.Step(`^there should be an? (violation|warning) with "([^"]*)" code and "([^"]*)" term in the result$`, thereShouldBeAResultWithCodeAndTermInTheResult)
+	sc.Step(`^there should be an? (violation|warning) with message matching /(.*)/ in the result$`, thereShouldBeAResultWithMessageMatchingInTheResult)
+	sc.Step(`^there should be exactly (\d+) (violation|warning)s? in the result$`, thereShouldBeExactlyResultsInTheResult)
+	sc.Step(`^rule "([^"]*)" should have succeeded$`, ruleShouldHaveSucceeded)
+	sc.Step(`^the report should match golden file "([^"]*)"$`, theReportShouldMatchGoldenFile)
 
 	sc.After(tearDownScenario)
 }
 
+// buildCLI builds the ec CLI, at the version required by the acceptance
+// module, so it is not rebuilt by each scenario
+func buildCLI() error {
+	cmd := exec.Command("go", "build", "-o", cliPath, "github.com/conforma/cli")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("building ec: %w\n%s", err, stderr.String())
+	}
+
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	if err := buildCLI(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Exit(m.Run())
+}
+
 func TestFeatures(t *testing.T) {
+	// the pretty formatter interleaves the output of the scenarios running
+	// in parallel, the progress formatter reports the failures once all
+	// scenarios have run
+	format := "pretty"
+	if *concurrency > 1 {
+		format = "progress"
+	}
+
 	suite := godog.TestSuite{
 		ScenarioInitializer: InitializeScenario,
 		Options: &godog.Options{
-			Format:   "pretty",
+			Format:   format,
 			Paths:    []string{"features"},
 			TestingT: t, // Testing instance that will run subtests.
 			Strict:   true,
+			// Each scenario runs in its own temporary directory, so they
+			// can run in parallel.
+			Concurrency: *concurrency,
 		},
 	}
 
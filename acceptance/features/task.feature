@@ -26,3 +26,43 @@ Feature: Task Definition
         When input is validated
         Then there should be no violations in the result
         Then there should be no warnings in the result
+
+    Scenario: Missing required result
+        Given a sample policy input "clamav-task"
+        And a policy config:
+            """
+            {
+                "sources": [
+                    {
+                        "policy": [
+                            "$GITROOT/policy/lib",
+                            "$GITROOT/policy/task"
+                        ],
+                        "data": [
+                            "$GITROOT/example/data"
+                        ],
+                        "ruleData": {
+                            "required_task_results": [
+                                {
+                                    "task": "clamav-scan",
+                                    "result": "SCAN_OUTPUT"
+                                }
+                            ]
+                        },
+                        "config": {
+                            "include": [
+                                "kind.*",
+                                "results.*"
+                            ]
+                        }
+                    }
+                ]
+            }
+            """
+        When input is validated
+        Then there should be exactly 1 violation in the result
+         And there should be a violation with "results.required" code in the result
+         And there should be a violation with message matching /"SCAN_OUTPUT" result not found in "clamav-scan" Task/ in the result
+         And there should be exactly 0 warnings in the result
+         And rule "results.rule_data_provided" should have succeeded
+         And rule "kind.expected_kind" should have succeeded
@@ -18,7 +18,9 @@ package asciidoc
 
 import (
 	_ "embed"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -32,11 +34,12 @@ import (
 )
 
 type doc struct {
-	Name        string
-	Qualifier   string
-	Description string
+	Kind
 	Packages    *[]pkg
 	Collections *[]col
+	// origins records the root each documented file was read from, the
+	// policy kind of a file is the kind of its root
+	origins origins
 }
 
 func (d *doc) SetAnnotations(a []ast.FlatAnnotationsRefSet) {
@@ -45,17 +48,17 @@ func (d *doc) SetAnnotations(a []ast.FlatAnnotationsRefSet) {
 	for _, set := range a {
 		rules := make([]*ast.Annotations, 0, 5)
 		for _, ref := range set {
-			pkgPath := ref.GetPackage().Path.String()
-			locationPrefix := filepath.Join("policy", d.Qualifier)
-			if strings.HasPrefix(ref.Location.File, locationPrefix) {
-				if strings.Contains(pkgPath, ".collection.") {
-					c := col{ref.Annotations, nil}
-					c.SetAnnotations(a)
-					collections = append(collections, c)
+			if d.owns(ref) {
+				if isCollection(ref) {
+					if ref.Annotations.Scope == "package" {
+						c := col{Annotations: ref.Annotations, Qualifier: d.Qualifier}
+						c.SetAnnotations(a, d.owns)
+						collections = append(collections, c)
+					}
 				} else {
 					switch ref.Annotations.Scope {
 					case "package":
-						packages = append(packages, pkg{ref.Annotations, &rules})
+						packages = append(packages, pkg{Annotations: ref.Annotations, Origin: d.Qualifier, Rules: &rules})
 					case "rule":
 						rules = append(rules, ref.Annotations)
 					}
@@ -68,12 +71,10 @@ func (d *doc) SetAnnotations(a []ast.FlatAnnotationsRefSet) {
 		})
 	}
 
-	if len(packages) > 0 {
-		sort.Slice(packages, func(i, j int) bool {
-			return packages[i].Annotations.Title < packages[j].Annotations.Title
-		})
-		d.Packages = &packages
-	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Annotations.Title < packages[j].Annotations.Title
+	})
+	d.Packages = &packages
 
 	if len(collections) > 0 {
 		sort.Slice(collections, func(i, j int) bool {
@@ -83,46 +84,133 @@ func (d *doc) SetAnnotations(a []ast.FlatAnnotationsRefSet) {
 	}
 }
 
-func (d doc) generateNav(module string) error {
-	navpath := filepath.Join(module, "partials", d.Qualifier+"_policy_nav.adoc")
-	nav, err := os.Create(navpath)
+// owns returns true if the annotated rule or package belongs to the doc's
+// policy kind
+func (d doc) owns(ref *ast.AnnotationsRef) bool {
+	return d.origins.kind(ref.Location.File) == d.Qualifier
+}
+
+// asciidocRenderer renders the Antora module: a navigation partial and a
+// policy page for each policy kind, a page for each package and for each
+// collection, the matrix of the rules against the collections, the timeline
+// of the rules' effective dates, and the reference of the library packages
+type asciidocRenderer struct{}
+
+func (asciidocRenderer) render(w writer, t templateSet, docs []doc) error {
+	for _, d := range docs {
+		if err := w(filepath.Join("partials", d.Qualifier+"_policy_nav.adoc"), execute(t["nav.template"], d)); err != nil {
+			return err
+		}
+
+		if err := w(filepath.Join("pages", d.Qualifier+"_policy.adoc"), execute(t["policy.template"], d)); err != nil {
+			return err
+		}
+
+		for _, p := range *d.Packages {
+			path := filepath.Join("pages", "packages", p.Origin+"_"+packageName(&p)+".adoc")
+			if err := w(path, execute(t["package.template"], &p)); err != nil {
+				return err
+			}
+		}
+
+		if d.Collections == nil {
+			continue
+		}
+
+		for _, c := range *d.Collections {
+			path := filepath.Join("pages", "collections", d.Qualifier+"_"+c.Name()+".adoc")
+			if err := w(path, execute(t["collection.template"], c)); err != nil {
+				return err
+			}
+		}
+	}
+
+	matrices, err := collectionMatrices(docs)
 	if err != nil {
-		return fmt.Errorf("creating file %q: %w", navpath, err)
+		return err
 	}
-	defer nav.Close()
 
-	return navTemplate.Execute(nav, d)
-}
+	for _, m := range matrices {
+		if err := w(filepath.Join("pages", matrixPage(m.Kind)+".adoc"), execute(t["collection_matrix.template"], m)); err != nil {
+			return err
+		}
+	}
 
-func (d doc) generatePolicy(module string) error {
-	navpath := filepath.Join(module, "pages", d.Qualifier+"_policy.adoc")
-	nav, err := os.Create(navpath)
+	rules, err := effectiveRules(docs)
 	if err != nil {
-		return fmt.Errorf("creating file %q: %w", navpath, err)
+		return err
 	}
-	defer nav.Close()
 
-	return policyTemplate.Execute(nav, d)
+	return w(filepath.Join("pages", timelinePage+".adoc"), execute(t["timeline.template"], rules))
 }
 
-func (d doc) generatePackage(module string, p pkg) error {
-	navpath := filepath.Join(module, "pages", "packages", policyOrigin(p.Annotations)+"_"+packageName(&p)+".adoc")
-	nav, err := os.Create(navpath)
-	if err != nil {
-		return fmt.Errorf("creating file %q: %w", navpath, err)
+func (asciidocRenderer) renderLibrary(w writer, t templateSet, packages []libPackage) error {
+	if err := w(filepath.Join("partials", libraryPage+"_nav.adoc"), execute(t["library_nav.template"], packages)); err != nil {
+		return err
+	}
+
+	if err := w(filepath.Join("pages", libraryPage+".adoc"), execute(t["library.template"], packages)); err != nil {
+		return err
 	}
-	defer nav.Close()
-	
-	return packageTemplate.Execute(nav, &p)
+
+	for _, p := range packages {
+		if err := w(filepath.Join("pages", libraryPage, p.Page()+".adoc"), execute(t["library_package.template"], p)); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
+func (asciidocRenderer) templates() map[string]string {
+	return map[string]string{
+		"nav.template":               navTemplateText,
+		"policy.template":            policyTemplateText,
+		"package.template":           packageTemplateText,
+		"collection.template":        collectionTemplateText,
+		"timeline.template":          timelineTemplateText,
+		"collection_matrix.template": collectionMatrixTemplateText,
+		"library_nav.template":       libraryNavTemplateText,
+		"library.template":           libraryTemplateText,
+		"library_package.template":   libraryPackageTemplateText,
+	}
+}
+
+// assetPath places the files in the attachments family directory so they can
+// be linked to and downloaded from the published site
+func (asciidocRenderer) assetPath(name string) string {
+	return filepath.Join("attachments", name)
+}
+
+// sourceURL links to the revision of this repository the site is built from
+func (asciidocRenderer) sourceURL() string {
+	return "https://github.com/conforma/policy/blob/{page-origin-refhash}"
+}
 
+// isCollection returns true if the annotated package, or a rule within it,
+// is a collection, i.e. its name is within a collection package, e.g.
+// collection.minimal or policy.task.collection.minimal
+func isCollection(ref *ast.AnnotationsRef) bool {
+	path := ref.GetPackage().Path
+	if len(path) < 3 {
+		return false
+	}
+
+	return path[len(path)-2].Equal(ast.StringTerm("collection"))
+}
+
+// col is a collection of rules, the rules list the collection's title in their
+// custom.collections annotation
 type col struct {
 	*ast.Annotations
-	Rules *[]*ast.Annotations
+	// Qualifier is the qualifier of the policy kind the collection belongs to
+	Qualifier string
+	Rules     *[]*ast.Annotations
 }
 
-func (c *col) SetAnnotations(a []ast.FlatAnnotationsRefSet) {
+// SetAnnotations finds the rules, owned by the same policy kind, included in
+// the collection
+func (c *col) SetAnnotations(a []ast.FlatAnnotationsRefSet, owns func(*ast.AnnotationsRef) bool) {
 	rules := make([]*ast.Annotations, 0, 5)
 	packageAnnotations := map[string]*pkg{}
 	title := c.Annotations.Title
@@ -130,7 +218,10 @@ func (c *col) SetAnnotations(a []ast.FlatAnnotationsRefSet) {
 		for _, ref := range set {
 			a := ref.Annotations
 			if a.Scope == "package" {
-				packageAnnotations[ref.Path.String()] = &pkg{a, nil}
+				packageAnnotations[ref.Path.String()] = &pkg{Annotations: a}
+			}
+			if !owns(ref) {
+				continue
 			}
 			if cs, ok := ref.Annotations.Custom["collections"].([]any); ok {
 				pkgPath := ref.GetPackage().Path.String()
@@ -151,15 +242,77 @@ func (c *col) SetAnnotations(a []ast.FlatAnnotationsRefSet) {
 	}
 
 	sort.Slice(rules, func(i, j int) bool {
-		return rules[i].Custom["package_title"].(string)+rules[i].Title < rules[j].Custom["package_title"].(string)+rules[j].Title
+		return fmt.Sprint(rules[i].Custom["package_title"])+rules[i].Title < fmt.Sprint(rules[j].Custom["package_title"])+rules[j].Title
 	})
 
 	c.Rules = &rules
 }
 
+// Name returns the name of the collection's package, e.g. minimal for the
+// collection.minimal package
+func (c col) Name() string {
+	path := c.GetTargetPath()
+	return strings.Trim(path[len(path)-1].String(), `"`)
+}
+
+// colPackage holds the rules of a collection from a single package
+type colPackage struct {
+	Name  string
+	Title string
+	Rules []*ast.Annotations
+}
+
+// Packages returns the rules of the collection grouped by their package
+func (c col) Packages() []colPackage {
+	packages := make([]colPackage, 0, 5)
+	for _, r := range *c.Rules {
+		name := fmt.Sprint(r.Custom["package_name"])
+		if len(packages) == 0 || packages[len(packages)-1].Name != name {
+			packages = append(packages, colPackage{Name: name, Title: fmt.Sprint(r.Custom["package_title"])})
+		}
+
+		last := &packages[len(packages)-1]
+		last.Rules = append(last.Rules, r)
+	}
+
+	return packages
+}
+
+// Failures returns the number of rules in the collection reporting failures
+func (c col) Failures() int {
+	return c.count("deny")
+}
+
+// Warnings returns the number of rules in the collection reporting warnings
+func (c col) Warnings() int {
+	return c.count("warn")
+}
+
+func (c col) count(kind string) int {
+	n := 0
+	for _, r := range *c.Rules {
+		if t, _ := ruleType(r); t == kind {
+			n++
+		}
+	}
+
+	return n
+}
+
 type pkg struct {
 	*ast.Annotations
-	Rules *[]*ast.Annotations
+	// Origin is the policy kind the package belongs to, e.g. release
+	Origin string
+	Rules  *[]*ast.Annotations
+	// Graph holds the dependencies between all documented rules
+	Graph *graph
+	// RuleData holds the rule data keys read by the rules
+	RuleData *ruleData
+	// Examples holds the worked examples of the rules taken from their tests
+	Examples *examples
+	// Coverage holds the test coverage of the package and its rules, nil
+	// unless a coverage report was provided
+	Coverage *coverage
 }
 
 func (p *pkg) path() []string {
@@ -175,34 +328,6 @@ func (p *pkg) path() []string {
 	return path
 }
 
-var docs = []doc{
-	{
-		Name:        "Release",
-		Qualifier:   "release",
-		Description: "These rules are applied to pipeline run attestations associated with container images built by Konflux.",
-	},
-	{
-		Name:        "Pipeline",
-		Qualifier:   "pipeline",
-		Description: "These rules are applied to Tekton pipeline definitions.",
-	},
-	{
-		Name:        "Task",
-		Qualifier:   "task",
-		Description: "These rules are applied to Tekton task definitions.",
-	},
-	{
-		Name:        "Build Task",
-		Qualifier:   "build_task",
-		Description: "These rules are applied to Tekton build task definitions.",
-	},
-	{
-		Name:        "StepAction",
-		Qualifier:   "stepaction",
-		Description: "These rules are applied to Tekton StepAction definitions.",
-	},
-}
-
 //go:embed nav.template
 var navTemplateText string
 
@@ -212,28 +337,37 @@ var policyTemplateText string
 //go:embed package.template
 var packageTemplateText string
 
-var navTemplate *template.Template
-
-var policyTemplate *template.Template
-
-var packageTemplate *template.Template
-
-func init() {
-	funcs := template.FuncMap{
-		"anchor":           anchor,
-		"packageName":      packageName,
-		"warningOrFailure": warningOrFailure,
-		"toUpper":          strings.ToUpper,
-		"toTitle":          strings.ToTitle,
-		"isBuiltIn":        isBuiltIn,
-		"policyOrigin":     policyOrigin,
-	}
-
-	navTemplate = template.Must(template.New("nav").Funcs(funcs).Parse(navTemplateText))
-
-	policyTemplate = template.Must(template.New("policy").Funcs(funcs).Parse(policyTemplateText))
-
-	packageTemplate = template.Must(template.New("Package").Funcs(funcs).Parse(packageTemplateText))
+//go:embed collection.template
+var collectionTemplateText string
+
+//go:embed timeline.template
+var timelineTemplateText string
+
+//go:embed collection_matrix.template
+var collectionMatrixTemplateText string
+
+//go:embed library_nav.template
+var libraryNavTemplateText string
+
+//go:embed library.template
+var libraryTemplateText string
+
+//go:embed library_package.template
+var libraryPackageTemplateText string
+
+var funcs = template.FuncMap{
+	"anchor":           anchor,
+	"packageName":      packageName,
+	"warningOrFailure": warningOrFailure,
+	"toUpper":          strings.ToUpper,
+	"toTitle":          strings.ToTitle,
+	"isBuiltIn":        isBuiltIn,
+	"cell":             cell,
+	"changeSummary":    changeSummary,
+	"customString":     customString,
+	"customStrings":    customStrings,
+	"ruleType":         ruleType,
+	"deprecation":      deprecation,
 }
 
 func packageName(p *pkg) string {
@@ -241,42 +375,67 @@ func packageName(p *pkg) string {
 	return path[len(path)-1]
 }
 
-func anchor(a *ast.Annotations) string {
+func anchor(a *ast.Annotations) (string, error) {
 	path := a.GetTargetPath()
 	switch a.Scope {
 	case "package":
 		significant := path[len(path)-1]
 		pkg, err := strconv.Unquote(significant.String())
 		if err != nil {
-			panic(err)
+			return "", fmt.Errorf("unable to determine the package name from path %q: %w", path, err)
 		}
-		return pkg + "_package"
+		return pkg + "_package", nil
 	case "rule":
+		if len(path) < 2 {
+			return "", fmt.Errorf("the rule path %q is too short to determine the package", path)
+		}
 		significant := path[len(path)-2]
 		pkg, err := strconv.Unquote(significant.String())
 		if err != nil {
-			panic(err)
+			return "", fmt.Errorf("unable to determine the package name from path %q: %w", path, err)
+		}
+		shortName, ok := a.Custom["short_name"].(string)
+		if !ok {
+			return "", fmt.Errorf("the rule %q has no custom.short_name annotation", path)
 		}
-		return pkg + "__" + a.Custom["short_name"].(string)
+		return pkg + "__" + shortName, nil
 	}
 
-	panic("expecting to be called for package or rules, was called for: " + a.Scope)
+	return "", fmt.Errorf("expecting to be called for package or rules, was called for: %s", a.Scope)
 }
 
-func warningOrFailure(a *ast.Annotations) string {
+// ruleType returns "deny" or "warn" depending on the kind of rule the
+// annotations are attached to
+func ruleType(a *ast.Annotations) (string, error) {
 	path := a.GetTargetPath().String()
 
 	if strings.HasSuffix(path, ".deny") {
-		return "failure"
+		return "deny", nil
 	}
 
 	if strings.HasSuffix(path, ".warn") {
-		return "warning"
+		return "warn", nil
+	}
+
+	return "", fmt.Errorf("the rule path %q does not end in .deny or .warn", path)
+}
+
+func warningOrFailure(a *ast.Annotations) (string, error) {
+	t, err := ruleType(a)
+	if err != nil {
+		return "", err
+	}
+
+	if t == "deny" {
+		return "failure", nil
 	}
 
-	panic(fmt.Sprintf("can't reduce to warning or failure rule with the path: %q", path))
+	return "warning", nil
 }
 
+// isBuiltIn returns true if the rule is in the builtin collection. The
+// embedded templates no longer use it, the source function tells whether a
+// rule is linked to, it is kept for the templates given with -templates.
 func isBuiltIn(a *ast.Annotations) bool {
 	if cs, ok := a.Custom["collections"].([]any); ok {
 		for _, c := range cs {
@@ -289,7 +448,13 @@ func isBuiltIn(a *ast.Annotations) bool {
 	return false
 }
 
-func inspect(rego []string) ([]ast.FlatAnnotationsRefSet, error) {
+// inspect parses all non-test Rego files found under the given directories
+// and returns their flattened annotations along with all the parsed modules,
+// recording the root each file was read from in the origins. Parse and
+// annotation errors do not stop the inspection, they are collected, with the
+// file and row they were found at, and returned together as ast.Errors once
+// all files have been processed.
+func inspect(roots []root, o origins) ([]ast.FlatAnnotationsRefSet, []*ast.Module, error) {
 	options := ast.ParserOptions{
 		ProcessAnnotation: true,
 		JSONOptions: &json.Options{
@@ -302,10 +467,12 @@ func inspect(rego []string) ([]ast.FlatAnnotationsRefSet, error) {
 	}
 
 	annotations := make([]ast.FlatAnnotationsRefSet, 0, 50)
+	modules := make([]*ast.Module, 0, 100)
+	var problems ast.Errors
 
-	for _, r := range rego {
-		fileSystem := os.DirFS(r)
-		fs.WalkDir(fileSystem, ".", func(path string, d fs.DirEntry, err error) error {
+	for _, r := range roots {
+		fileSystem := os.DirFS(r.Path)
+		err := fs.WalkDir(fileSystem, ".", func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
@@ -319,14 +486,20 @@ func inspect(rego []string) ([]ast.FlatAnnotationsRefSet, error) {
 				return err
 			}
 
-			mod, err := ast.ParseModuleWithOpts(path, string(data), options)
+			o.add(r.file(path), r, path)
+
+			mod, err := ast.ParseModuleWithOpts(r.file(path), string(data), options)
 			if err != nil {
-				return err
+				problems = append(problems, locate(r, path, err)...)
+				return nil
 			}
 
-			as, x := ast.BuildAnnotationSet([]*ast.Module{mod})
-			if len(x) > 0 {
-				return err
+			modules = append(modules, mod)
+
+			as, errs := ast.BuildAnnotationSet([]*ast.Module{mod})
+			if len(errs) > 0 {
+				problems = append(problems, locate(r, path, errs)...)
+				return nil
 			}
 
 			ann := as.Flatten()
@@ -337,40 +510,283 @@ func inspect(rego []string) ([]ast.FlatAnnotationsRefSet, error) {
 
 			return nil
 		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("inspecting %q: %w", r.Path, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, nil, problems
+	}
+
+	return annotations, modules, nil
+}
+
+// locate converts the error returned by the OPA parser or the annotation set
+// builder to ast.Errors, with the file locations made relative to the working
+// directory rather than to the inspected root so they can be followed by the
+// reader.
+func locate(r root, path string, err error) ast.Errors {
+	var errs ast.Errors
+	if !errors.As(err, &errs) {
+		return ast.Errors{ast.NewError(ast.ParseErr, nil, "%v", err)}
+	}
+
+	located := make(ast.Errors, 0, len(errs))
+	for _, e := range errs {
+		c := *e
+		if e.Location != nil {
+			l := *e.Location
+			if l.File == r.file(path) {
+				l.File = filepath.Join(r.Path, filepath.FromSlash(path))
+			}
+			c.Location = &l
+		}
+		located = append(located, &c)
 	}
 
-	return annotations, nil
+	return located
 }
 
-func GenerateAsciidoc(module string, rego ...string) error {
-	annotations, err := inspect(rego)
+// Options control how the documentation is generated
+type Options struct {
+	// Format is the output format, one of Formats(), asciidoc by default
+	Format string
+	// Kinds are the policy kinds to document, discovered from the
+	// directories under policy/ within the Rego directories if empty
+	Kinds []Kind
+	// Graph is the format, one of GraphFormats(), of the rule dependency
+	// graph to write alongside the pages, no graph is written if empty
+	Graph string
+	// Catalog enables writing the machine readable rule catalog, rules.json,
+	// alongside the pages
+	Catalog bool
+	// Templates is a directory with templates overriding the embedded
+	// templates of the same name, e.g. package.template
+	Templates string
+	// Coverage is the OPA test coverage report, `opa test --coverage --format
+	// json`, used to show the test coverage of the packages and the rules, no
+	// coverage is shown if empty
+	Coverage string
+	// SourceURL is the base of the links to the source of the files in the
+	// unnamed Rego directories that do not declare a URL, e.g.
+	// https://github.com/conforma/policy/blob/v1.0.0, by default the AsciiDoc
+	// pages link to the revision the site is built from and the Markdown
+	// pages do not link to the files
+	SourceURL string
+	// Warnings receives the problems found that do not prevent generating the
+	// documentation, e.g. library functions without a description, they are
+	// discarded if nil
+	Warnings io.Writer
+}
+
+// GenerateAsciidoc renders the navigation, policy and package pages for each
+// of the given policy kinds into the Antora module directory. When no kinds
+// are given they are discovered from the directories under policy/ within the
+// Rego directories.
+func GenerateAsciidoc(module string, kinds []Kind, rego ...string) error {
+	return Generate(module, Options{Format: "asciidoc", Kinds: kinds}, rego...)
+}
+
+// Generate renders the documentation of the rules found in the Rego
+// directories into the output directory using the format chosen in the
+// options. Files generated previously, but no longer generated, e.g. the page
+// of a removed package, are removed from the output directory.
+func Generate(out string, opts Options, rego ...string) error {
+	p, err := generate(opts, rego)
 	if err != nil {
 		return err
 	}
 
-	for _, d := range docs {
-		d.SetAnnotations(annotations)
-		if err := d.generateNav(module); err != nil {
-			return err
-		}
-		if err := d.generatePolicy(module); err != nil {
-			return err
+	return p.sync(out)
+}
+
+// Check renders the documentation like Generate does, but only in memory, and
+// returns the unified diff between the output directory and the generated
+// files. The diff is empty when the output directory is up to date.
+func Check(out string, opts Options, rego ...string) (string, error) {
+	p, err := generate(opts, rego)
+	if err != nil {
+		return "", err
+	}
+
+	return p.diff(out)
+}
+
+// generate renders the documentation into memory
+func generate(opts Options, rego []string) (pages, error) {
+	if opts.Format == "" {
+		opts.Format = "asciidoc"
+	}
+
+	r, ok := renderers[opts.Format]
+	if !ok {
+		return nil, fmt.Errorf("unsupported format %q, expecting one of: %s", opts.Format, strings.Join(Formats(), ", "))
+	}
+
+	if _, ok := graphFormats[opts.Graph]; opts.Graph != "" && !ok {
+		return nil, fmt.Errorf("unsupported graph format %q, expecting one of: %s", opts.Graph, strings.Join(GraphFormats(), ", "))
+	}
+
+	m, err := load(opts.Kinds, rego)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Coverage != "" {
+		c, err := mapCoverage(opts.Coverage, m)
+		if err != nil {
+			return nil, err
 		}
-		for _, p := range *d.Packages {
-			if err := d.generatePackage(module, p); err != nil {
-				return err
+
+		for _, d := range m.docs {
+			for i := range *d.Packages {
+				(*d.Packages)[i].Coverage = c
 			}
 		}
 	}
 
-	return nil
+	base := opts.SourceURL
+	if base == "" {
+		base = r.sourceURL()
+	}
+
+	t, err := loadTemplates(r, opts.Templates, m.docs, sources{origins: m.origins, base: strings.TrimSuffix(base, "/")})
+	if err != nil {
+		return nil, err
+	}
+
+	p := pages{}
+	w := p.writer()
+	if err := r.render(w, t, m.docs); err != nil {
+		return nil, err
+	}
+
+	if err := r.renderLibrary(w, t, m.library); err != nil {
+		return nil, err
+	}
+
+	if opts.Warnings != nil {
+		for _, warning := range m.libraryWarnings {
+			fmt.Fprintln(opts.Warnings, warning)
+		}
+	}
+
+	if opts.Graph != "" {
+		if err := w(r.assetPath(graphFormats[opts.Graph]), writeGraph(m.graph, opts.Graph)); err != nil {
+			return nil, err
+		}
+	}
+
+	matrices, err := collectionMatrices(m.docs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mx := range matrices {
+		if err := w(r.assetPath(matrixPage(mx.Kind)+".csv"), writeMatrixCSV(mx)); err != nil {
+			return nil, err
+		}
+
+		if err := w(r.assetPath(matrixPage(mx.Kind)+".json"), writeMatrixJSON(mx)); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Catalog {
+		c, err := catalog(m.docs)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := w(r.assetPath(catalogFile), writeCatalog(c)); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// model is the documentation model of all rules found in the Rego directories
+type model struct {
+	docs        []doc
+	annotations []ast.FlatAnnotationsRefSet
+	modules     []*ast.Module
+	graph       *graph
+	ruleData    *ruleData
+	// library documents the library packages
+	library         []libPackage
+	libraryWarnings []libWarning
+	// roots are the directories the Rego files were read from
+	roots []root
+	// origins records the root each documented file was read from
+	origins origins
 }
 
-// obtain policy origin of a package or a rule
-func policyOrigin(a *ast.Annotations) string {
-	path := strings.Split(a.Location.File, "/")
-	if len(path) > 1 && path[0] == "policy" {
-		return path[1]
+// load inspects the Rego directories and builds the documentation model for
+// the given policy kinds, or for the discovered kinds if none are given
+func load(kinds []Kind, rego []string) (*model, error) {
+	roots, err := parseRoots(rego)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(kinds) == 0 {
+		var err error
+		if kinds, err = DiscoverKinds(rego...); err != nil {
+			return nil, err
+		}
+	}
+
+	o := origins{}
+	annotations, modules, err := inspect(roots, o)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]doc, 0, len(kinds))
+	for _, k := range kinds {
+		docs = append(docs, doc{Kind: k, origins: o})
+	}
+
+	if err := validate(docs, annotations); err != nil {
+		return nil, err
+	}
+
+	for i := range docs {
+		docs[i].SetAnnotations(annotations)
+	}
+
+	rd, err := analyseRuleData(modules, annotations, roots, o)
+	if err != nil {
+		return nil, err
+	}
+
+	ex, err := extractExamples(modules, annotations, roots, o)
+	if err != nil {
+		return nil, err
+	}
+
+	g := newGraph(docs, o)
+	for _, d := range docs {
+		for i := range *d.Packages {
+			(*d.Packages)[i].Graph = g
+			(*d.Packages)[i].RuleData = rd
+			(*d.Packages)[i].Examples = ex
+		}
 	}
-	return ""
+
+	library, libraryWarnings := libraryPackages(modules, annotations)
+
+	return &model{
+		library:         library,
+		libraryWarnings: libraryWarnings,
+		docs:            docs,
+		annotations:     annotations,
+		modules:         modules,
+		graph:           g,
+		ruleData:        rd,
+		roots:           roots,
+		origins:         o,
+	}, nil
 }
@@ -0,0 +1,230 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestInspectErrors(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		// root is the specification of the root, the temporary directory
+		// is prepended
+		root string
+		// want are the expected errors as file:row: code, the file relative
+		// to the temporary directory
+		want []string
+	}{
+		{
+			name: "valid",
+			files: map[string]string{
+				"policy/release/a/a.rego": "package a\n",
+			},
+		},
+		{
+			name: "parse error",
+			files: map[string]string{
+				"policy/release/a/a.rego": "package a\n\ndeny if {",
+				"policy/release/b/b.rego": "package b\n",
+			},
+			want: []string{"policy/release/a/a.rego:3: rego_parse_error"},
+		},
+		{
+			name: "all files are inspected",
+			files: map[string]string{
+				"policy/release/a/a.rego": "package a\n\ndeny if {",
+				"policy/release/b/b.rego": "# METADATA\n# scope: bogus\npackage b\n",
+			},
+			want: []string{
+				"policy/release/a/a.rego:3: rego_parse_error",
+				"policy/release/b/b.rego:1: rego_parse_error",
+			},
+		},
+		{
+			name: "annotation error",
+			files: map[string]string{
+				"policy/release/a/a.rego": "package a\n\n# METADATA\n# scope: document\n# title: x\nallow := true\n\n# METADATA\n# scope: document\n# title: y\nallow := false\n",
+			},
+			want: []string{"policy/release/a/a.rego:8: rego_type_error"},
+		},
+		{
+			name: "located within the root",
+			files: map[string]string{
+				"cli/a/a.rego": "package a\n\ndeny if {",
+			},
+			root: "/cli,kind=release",
+			want: []string{"cli/a/a.rego:3: rego_parse_error"},
+		},
+		{
+			name: "tests are not inspected",
+			files: map[string]string{
+				"policy/release/a/a_test.rego": "package a\n\ntest_a if {",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range c.files {
+				writeFile(t, dir, name, content)
+			}
+
+			r, err := parseRoot(dir + c.root)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, _, err = inspect([]root{r}, nil)
+			if len(c.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var errs ast.Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected ast.Errors, got %v", err)
+			}
+
+			got := make([]string, 0, len(errs))
+			for _, e := range errs {
+				rel, err := filepath.Rel(dir, e.Location.File)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, fmt.Sprintf("%s:%d: %s", filepath.ToSlash(rel), e.Location.Row, e.Code))
+			}
+
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestCollections(t *testing.T) {
+	dir := policyTree(t)
+	writeFile(t, dir, "policy/task/collection/basic/basic.rego", `# METADATA
+# title: basic
+# description: The basic task rules.
+package collection.basic
+`)
+	writeFile(t, dir, "policy/task/c/c.rego", "# METADATA\n# title: C\n"+conventionsModule("c",
+		"short_name: four\ncollections:\n- basic",
+		"short_name: five\ncollections:\n- minimal"))
+	// a release rule listing a task collection is not part of it
+	writeFile(t, dir, "policy/release/d/d.rego", "# METADATA\n# title: D\n"+conventionsModule("d",
+		"short_name: six\ncollections:\n- basic"))
+
+	m, err := load(nil, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := map[string][]string{}
+	for _, d := range m.docs {
+		if d.Collections == nil {
+			continue
+		}
+		for _, c := range *d.Collections {
+			summary := fmt.Sprintf("%s: %d failures, %d warnings", c.Qualifier, c.Failures(), c.Warnings())
+			for _, p := range c.Packages() {
+				codes := make([]string, 0, len(p.Rules))
+				for _, r := range p.Rules {
+					codes = append(codes, p.Name+"."+r.Custom["short_name"].(string))
+				}
+				summary += fmt.Sprintf(", %s (%s) %s", p.Title, p.Name, strings.Join(codes, " "))
+			}
+			got[c.Name()] = append(got[c.Name()], summary)
+		}
+	}
+
+	want := map[string][]string{
+		"minimal": {"release: 1 failures, 0 warnings, A (a) a.one"},
+		"strict":  {"release: 1 failures, 1 warnings, A (a) a.one a.two"},
+		"basic":   {"task: 1 failures, 0 warnings, C (c) c.four"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got collections %v, want %v", got, want)
+	}
+
+	p, err := generate(Options{}, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages := []struct {
+		path string
+		line string
+	}{
+		{"partials/task_policy_nav.adoc", "*** xref:collections/task_basic.adoc[basic]"},
+		{"pages/collections/task_basic.adoc", "      - github.com/conforma/policy//policy/task"},
+		{"pages/collections/task_basic.adoc", "        - '@basic'"},
+		{"pages/collections/task_basic.adoc", "=== xref:packages/task_c.adoc[C]"},
+		{"pages/collections/task_basic.adoc", "* xref:packages/task_c.adoc#c__four[A rule] [rule-type-indicator failure]#FAILURE#"},
+	}
+	for _, page := range pages {
+		if !slices.Contains(strings.Split(string(p[page.path]), "\n"), page.line) {
+			t.Errorf("missing line %q in %s:\n%s", page.line, page.path, p[page.path])
+		}
+	}
+}
+
+func TestDeprecationBanner(t *testing.T) {
+	dir := policyTree(t)
+	writeFile(t, dir, "policy/release/c/c.rego", "# METADATA\n# title: C\n"+conventionsModule("c",
+		"short_name: replaced\ndeprecated:\n  since: 2025-06-01T00:00:00Z\n  reason: Use a.one.\nreplaced_by: a.one",
+		"short_name: missing\ndeprecated:\n  since: 2025-06-01T00:00:00Z\n  reason: Use x.y.\nreplaced_by: x.y",
+		"short_name: dropped\ndeprecated:\n  since: 2025-06-01T00:00:00Z\n  reason: No longer needed.",
+		"short_name: current"))
+
+	p, err := generate(Options{}, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	page := string(p["pages/packages/release_c.adoc"])
+
+	// the rules share the title, so the order of the banners is not
+	// significant
+	got := []string{}
+	for _, b := range strings.Split(page, "[WARNING]\n.Deprecated\n====\n")[1:] {
+		got = append(got, b[:strings.Index(b, "\n====")])
+	}
+	slices.Sort(got)
+
+	want := []string{
+		"This rule is deprecated since `2025-06-01T00:00:00Z`: No longer needed.",
+		"This rule is deprecated since `2025-06-01T00:00:00Z`: Use a.one.\n\nUse xref:packages/release_a.adoc#a__one[a.one] instead.",
+		"This rule is deprecated since `2025-06-01T00:00:00Z`: Use x.y.\n\nUse `x.y` instead.",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got banners:\n%s\nwant:\n%s", strings.Join(got, "\n--\n"), strings.Join(want, "\n--\n"))
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// catalogFile is the name of the machine readable rule catalog
+const catalogFile = "rules.json"
+
+// Catalog is the machine readable list of all documented rules
+type Catalog struct {
+	Rules []CatalogRule `json:"rules"`
+}
+
+// CatalogRule describes a single rule within the Catalog
+type CatalogRule struct {
+	// Code is the package name and the rule's short name, e.g. tasks.required_tasks_found
+	Code         string `json:"code"`
+	Package      string `json:"package"`
+	PackageTitle string `json:"package_title"`
+	Title        string `json:"title"`
+	Description  string `json:"description"`
+	Solution     string `json:"solution,omitempty"`
+	FailureMsg   string `json:"failure_msg,omitempty"`
+	// Type is either deny or warn
+	Type        string   `json:"type"`
+	Collections []string `json:"collections"`
+	EffectiveOn string   `json:"effective_on,omitempty"`
+	DependsOn   []string `json:"depends_on"`
+	// Deprecated is set for rules kept only for compatibility, ReplacedBy is
+	// the code of the rule to use instead, if any
+	Deprecated *Deprecation `json:"deprecated,omitempty"`
+	ReplacedBy string       `json:"replaced_by,omitempty"`
+	// RuleData are the rule data keys the rule reads
+	RuleData []string `json:"rule_data"`
+	Source   Source   `json:"source"`
+	// Origin is the policy kind the rule comes from, e.g. release
+	Origin string `json:"origin"`
+}
+
+// Source is the location of a rule's METADATA block
+type Source struct {
+	File string `json:"file"`
+	Row  int    `json:"row"`
+}
+
+// Deprecation is the custom.deprecated annotation of a rule, e.g.
+//
+//	deprecated:
+//	  since: 2025-06-01T00:00:00Z
+//	  reason: The result name does not follow the naming conventions.
+//	replaced_by: cve.cve_results_found
+type Deprecation struct {
+	// Since is the RFC3339 formatted date the rule was deprecated on
+	Since  string `json:"since"`
+	Reason string `json:"reason"`
+}
+
+// LoadCatalog inspects the Rego directories and returns the Catalog of the
+// rules of the given policy kinds, or of the discovered kinds if none are
+// given
+func LoadCatalog(kinds []Kind, rego ...string) (Catalog, error) {
+	m, err := load(kinds, rego)
+	if err != nil {
+		return Catalog{}, err
+	}
+
+	return catalog(m.docs)
+}
+
+// catalog builds the Catalog from the packages of the given policy kinds
+func catalog(docs []doc) (Catalog, error) {
+	rules := make([]CatalogRule, 0, 100)
+	for _, d := range docs {
+		for _, p := range *d.Packages {
+			for _, a := range *p.Rules {
+				r, err := catalogRule(&p, a)
+				if err != nil {
+					return Catalog{}, err
+				}
+				rules = append(rules, r)
+			}
+		}
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Code < rules[j].Code
+	})
+
+	return Catalog{Rules: rules}, nil
+}
+
+func catalogRule(p *pkg, a *ast.Annotations) (CatalogRule, error) {
+	t, err := ruleType(a)
+	if err != nil {
+		return CatalogRule{}, err
+	}
+
+	name := packageName(p)
+	r := CatalogRule{
+		Code:         fmt.Sprintf("%s.%s", name, a.Custom["short_name"]),
+		Package:      name,
+		PackageTitle: p.Title,
+		Title:        a.Title,
+		Description:  a.Description,
+		Solution:     customString(a, "solution"),
+		FailureMsg:   customString(a, "failure_msg"),
+		Type:         t,
+		Collections:  customStrings(a, "collections"),
+		EffectiveOn:  customString(a, "effective_on"),
+		DependsOn:    customStrings(a, "depends_on"),
+		Deprecated:   deprecation(a),
+		ReplacedBy:   customString(a, "replaced_by"),
+		RuleData:     p.RuleData.Names(a),
+		Origin:       p.Origin,
+	}
+
+	if a.Location != nil {
+		r.Source = Source{File: a.Location.File, Row: a.Location.Row}
+	}
+
+	return r, nil
+}
+
+// customString returns the custom annotation with the given key as a string,
+// empty if it is not set
+func customString(a *ast.Annotations, key string) string {
+	v, ok := a.Custom[key]
+	if !ok || v == nil {
+		return ""
+	}
+
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	return fmt.Sprint(v)
+}
+
+// customStrings returns the custom annotation with the given key as a list
+// of strings, empty if it is not set
+func customStrings(a *ast.Annotations, key string) []string {
+	vs, _ := a.Custom[key].([]any)
+
+	s := make([]string, 0, len(vs))
+	for _, v := range vs {
+		s = append(s, fmt.Sprint(v))
+	}
+
+	return s
+}
+
+// deprecation returns the custom.deprecated annotation, nil if the rule is
+// not deprecated
+func deprecation(a *ast.Annotations) *Deprecation {
+	v, ok := a.Custom["deprecated"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	d := Deprecation{}
+	if since := v["since"]; since != nil {
+		d.Since = fmt.Sprint(since)
+	}
+	if reason := v["reason"]; reason != nil {
+		d.Reason = fmt.Sprint(reason)
+	}
+
+	return &d
+}
+
+// writeCatalog writes the Catalog as indented JSON
+func writeCatalog(c Catalog) func(io.Writer) error {
+	return func(w io.Writer) error {
+		return WriteJSON(w, c)
+	}
+}
+
+// WriteJSON writes the value as indented JSON, the characters significant in
+// HTML are not escaped
+func WriteJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+
+	return enc.Encode(v)
+}
@@ -0,0 +1,147 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"bytes"
+	"testing"
+)
+
+// catalogPolicy is a deprecated rule reading rule data, added to the
+// policyTree
+const catalogPolicy = `# METADATA
+# title: C
+# description: Checks c.
+package c
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: Rule four
+# description: The fourth rule.
+# custom:
+#   short_name: four
+#   failure_msg: Four failed
+#   solution: Fix it.
+#   deprecated:
+#     since: 2025-06-01T00:00:00Z
+#     reason: Replaced.
+#   replaced_by: a.one
+deny contains "four" if {
+	lib.rule_data("a")
+}
+`
+
+func TestLoadCatalog(t *testing.T) {
+	dir := policyTree(t)
+	writeFile(t, dir, "policy/lib/lib.rego", ruleDataLib)
+	writeFile(t, dir, "policy/release/c/c.rego", catalogPolicy)
+
+	cases := []struct {
+		name  string
+		kinds []Kind
+		want  string
+	}{
+		{
+			name: "all kinds",
+			want: `{"rules": [
+				{"code": "a.one", "package": "a", "package_title": "A", "title": "Rule one",
+					"description": "The first rule.", "failure_msg": "One failed", "type": "deny",
+					"collections": ["minimal", "strict"], "effective_on": "2025-05-01T00:00:00Z",
+					"depends_on": [], "rule_data": [],
+					"source": {"file": "policy/release/a/a.rego", "row": 8}, "origin": "release"},
+				{"code": "a.two", "package": "a", "package_title": "A", "title": "Rule two",
+					"description": "The second rule.", "failure_msg": "Two failed", "type": "warn",
+					"collections": ["strict"], "depends_on": ["a.one"], "rule_data": [],
+					"source": {"file": "policy/release/a/a.rego", "row": 22}, "origin": "release"},
+				{"code": "b.three", "package": "b", "package_title": "B", "title": "Rule three",
+					"description": "The third rule.", "failure_msg": "Three failed", "type": "deny",
+					"collections": [], "effective_on": "2025-07-01T00:00:00Z", "depends_on": [],
+					"rule_data": [], "source": {"file": "policy/task/b/b.rego", "row": 8}, "origin": "task"},
+				{"code": "c.four", "package": "c", "package_title": "C", "title": "Rule four",
+					"description": "The fourth rule.", "solution": "Fix it.", "failure_msg": "Four failed",
+					"type": "deny", "collections": [], "depends_on": [],
+					"deprecated": {"since": "2025-06-01T00:00:00Z", "reason": "Replaced."},
+					"replaced_by": "a.one", "rule_data": ["a"],
+					"source": {"file": "policy/release/c/c.rego", "row": 10}, "origin": "release"}
+			]}`,
+		},
+		{
+			name:  "given kinds",
+			kinds: []Kind{{Name: "Task", Qualifier: "task"}},
+			want: `{"rules": [
+				{"code": "b.three", "package": "b", "package_title": "B", "title": "Rule three",
+					"description": "The third rule.", "failure_msg": "Three failed", "type": "deny",
+					"collections": [], "effective_on": "2025-07-01T00:00:00Z", "depends_on": [],
+					"rule_data": [], "source": {"file": "policy/task/b/b.rego", "row": 8}, "origin": "task"}
+			]}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			catalog, err := LoadCatalog(c.kinds, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got bytes.Buffer
+			if err := WriteJSON(&got, catalog); err != nil {
+				t.Fatal(err)
+			}
+
+			assertJSON(t, "catalog", got.String(), c.want)
+		})
+	}
+}
+
+func TestGenerateCatalog(t *testing.T) {
+	dir := policyTree(t)
+
+	cases := []struct {
+		name string
+		opts Options
+		// want is the path of the catalog, empty if none is written
+		want string
+	}{
+		{name: "asciidoc", opts: Options{Format: "asciidoc", Catalog: true}, want: "attachments/rules.json"},
+		{name: "markdown", opts: Options{Format: "markdown", Catalog: true}, want: "rules.json"},
+		{name: "disabled", opts: Options{Format: "asciidoc"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := generate(c.opts, []string{dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			for _, path := range p.paths() {
+				if path == catalogFile || path == "attachments/"+catalogFile {
+					got = path
+				}
+			}
+
+			if got != c.want {
+				t.Errorf("got catalog %q, want %q", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	_ "embed"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Changelog holds the differences between the rules of two Catalogs
+type Changelog struct {
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Added   []CatalogRule `json:"added"`
+	Removed []CatalogRule `json:"removed"`
+	Changed []RuleChange  `json:"changed"`
+}
+
+// RuleChange lists the changes made to a rule present in both Catalogs
+type RuleChange struct {
+	Code    string        `json:"code"`
+	Origin  string        `json:"origin"`
+	Title   string        `json:"title"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange describes a change of a single attribute of a rule
+type FieldChange struct {
+	// Field is the name of the changed attribute as found in the catalog,
+	// e.g. type or collections
+	Field string `json:"field"`
+	Old   any    `json:"old"`
+	New   any    `json:"new"`
+	// Added holds the values added to a list attribute, e.g. collections
+	Added []string `json:"added,omitempty"`
+	// Removed holds the values removed from a list attribute
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Empty returns true if there are no differences
+func (c Changelog) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// DiffCatalogs compares the rules of two Catalogs. Rules are matched by their
+// policy kind and code. The from and to are descriptions, e.g. git refs, of
+// the compared Catalogs.
+func DiffCatalogs(from string, old Catalog, to string, new Catalog) Changelog {
+	c := Changelog{
+		From:    from,
+		To:      to,
+		Added:   []CatalogRule{},
+		Removed: []CatalogRule{},
+		Changed: []RuleChange{},
+	}
+
+	key := func(r CatalogRule) string {
+		return r.Origin + "/" + r.Code
+	}
+
+	oldRules := map[string]CatalogRule{}
+	for _, r := range old.Rules {
+		oldRules[key(r)] = r
+	}
+
+	newRules := map[string]CatalogRule{}
+	for _, r := range new.Rules {
+		newRules[key(r)] = r
+	}
+
+	for _, r := range new.Rules {
+		o, ok := oldRules[key(r)]
+		if !ok {
+			c.Added = append(c.Added, r)
+			continue
+		}
+
+		if changes := diffRule(o, r); len(changes) > 0 {
+			c.Changed = append(c.Changed, RuleChange{
+				Code:    r.Code,
+				Origin:  r.Origin,
+				Title:   r.Title,
+				Changes: changes,
+			})
+		}
+	}
+
+	for _, r := range old.Rules {
+		if _, ok := newRules[key(r)]; !ok {
+			c.Removed = append(c.Removed, r)
+		}
+	}
+
+	sortRules := func(rs []CatalogRule) {
+		sort.Slice(rs, func(i, j int) bool {
+			return key(rs[i]) < key(rs[j])
+		})
+	}
+	sortRules(c.Added)
+	sortRules(c.Removed)
+
+	sort.Slice(c.Changed, func(i, j int) bool {
+		return c.Changed[i].Origin+"/"+c.Changed[i].Code < c.Changed[j].Origin+"/"+c.Changed[j].Code
+	})
+
+	return c
+}
+
+// diffRule returns the changes of the attributes significant to the users of
+// a rule, the location of the rule in the source is not considered
+func diffRule(old, new CatalogRule) []FieldChange {
+	changes := make([]FieldChange, 0, 2)
+
+	strs := []struct {
+		field    string
+		old, new string
+	}{
+		{"type", old.Type, new.Type},
+		{"effective_on", old.EffectiveOn, new.EffectiveOn},
+		{"title", old.Title, new.Title},
+		{"description", old.Description, new.Description},
+		{"solution", old.Solution, new.Solution},
+		{"failure_msg", old.FailureMsg, new.FailureMsg},
+		{"replaced_by", old.ReplacedBy, new.ReplacedBy},
+	}
+	for _, s := range strs {
+		if s.old != s.new {
+			changes = append(changes, FieldChange{Field: s.field, Old: s.old, New: s.new})
+		}
+	}
+
+	if !sameDeprecation(old.Deprecated, new.Deprecated) {
+		changes = append(changes, FieldChange{Field: "deprecated", Old: old.Deprecated, New: new.Deprecated})
+	}
+
+	lists := []struct {
+		field    string
+		old, new []string
+	}{
+		{"collections", old.Collections, new.Collections},
+		{"depends_on", old.DependsOn, new.DependsOn},
+		{"rule_data", old.RuleData, new.RuleData},
+	}
+	for _, l := range lists {
+		added, removed := difference(l.new, l.old), difference(l.old, l.new)
+		if len(added) > 0 || len(removed) > 0 {
+			changes = append(changes, FieldChange{
+				Field:   l.field,
+				Old:     l.old,
+				New:     l.new,
+				Added:   added,
+				Removed: removed,
+			})
+		}
+	}
+
+	return changes
+}
+
+func sameDeprecation(a, b *Deprecation) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// difference returns the values in a that are not in b
+func difference(a, b []string) []string {
+	d := make([]string, 0, len(a))
+	for _, v := range a {
+		if !slices.Contains(b, v) {
+			d = append(d, v)
+		}
+	}
+
+	return d
+}
+
+// fieldNames are the human readable names of the catalog attributes
+var fieldNames = map[string]string{
+	"type":         "Rule type",
+	"effective_on": "Effective from",
+	"title":        "Title",
+	"description":  "Description",
+	"solution":     "Solution",
+	"failure_msg":  "Failure message",
+	"replaced_by":  "Replaced by",
+	"collections":  "collections",
+	"depends_on":   "dependencies",
+	"rule_data":    "rule data keys",
+}
+
+// changeSummary describes the change in a sentence usable in both Asciidoc
+// and Markdown
+func changeSummary(c FieldChange) string {
+	name := fieldNames[c.Field]
+	if name == "" {
+		name = c.Field
+	}
+
+	quote := func(vs []string) string {
+		q := make([]string, 0, len(vs))
+		for _, v := range vs {
+			q = append(q, "`"+v+"`")
+		}
+		return strings.Join(q, ", ")
+	}
+
+	if c.Added != nil || c.Removed != nil {
+		parts := make([]string, 0, 2)
+		if len(c.Added) > 0 {
+			parts = append(parts, fmt.Sprintf("added to %s %s", name, quote(c.Added)))
+		}
+		if len(c.Removed) > 0 {
+			parts = append(parts, fmt.Sprintf("removed from %s %s", name, quote(c.Removed)))
+		}
+		s := strings.Join(parts, ", ")
+		return strings.ToUpper(s[:1]) + s[1:]
+	}
+
+	switch c.Field {
+	case "description", "solution":
+		return name + " changed"
+	case "deprecated":
+		o, _ := c.Old.(*Deprecation)
+		d, _ := c.New.(*Deprecation)
+		switch {
+		case d == nil:
+			return "No longer deprecated"
+		case o == nil:
+			return fmt.Sprintf("Deprecated since `%s`: %s", d.Since, d.Reason)
+		}
+
+		return fmt.Sprintf("Deprecation changed, deprecated since `%s`: %s", d.Since, d.Reason)
+	}
+
+	old, new := fmt.Sprint(c.Old), fmt.Sprint(c.New)
+	switch {
+	case old == "":
+		return fmt.Sprintf("%s set to `%s`", name, new)
+	case new == "":
+		return fmt.Sprintf("%s `%s` removed", name, old)
+	}
+
+	return fmt.Sprintf("%s changed from `%s` to `%s`", name, old, new)
+}
+
+//go:embed changelog.template
+var changelogTemplateText string
+
+//go:embed changelog.md.template
+var changelogMarkdownTemplateText string
+
+var changelogTemplates = map[string]*template.Template{
+	"asciidoc": template.Must(template.New("changelog").Funcs(funcs).Parse(changelogTemplateText)),
+	"markdown": template.Must(template.New("changelog.md").Funcs(funcs).Parse(changelogMarkdownTemplateText)),
+}
+
+// WriteChangelog writes the Changelog in the given format, one of json,
+// asciidoc or markdown
+func WriteChangelog(w io.Writer, c Changelog, format string) error {
+	if format == "json" {
+		return WriteJSON(w, c)
+	}
+
+	t, ok := changelogTemplates[format]
+	if !ok {
+		return fmt.Errorf("unsupported changelog format %q, expecting one of: json, %s", format, strings.Join(Formats(), ", "))
+	}
+
+	return t.Execute(w, c)
+}
@@ -0,0 +1,326 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffCatalogs(t *testing.T) {
+	rule := func(origin, code string) CatalogRule {
+		return CatalogRule{
+			Code:        code,
+			Origin:      origin,
+			Title:       "A rule",
+			Description: "A rule.",
+			Type:        "deny",
+			Collections: []string{"minimal"},
+			DependsOn:   []string{},
+			RuleData:    []string{},
+			Source:      Source{File: "policy/release/a/a.rego", Row: 1},
+		}
+	}
+	changed := func(origin, code string, change func(*CatalogRule)) CatalogRule {
+		r := rule(origin, code)
+		change(&r)
+		return r
+	}
+
+	cases := []struct {
+		name     string
+		old, new []CatalogRule
+		want     []string
+	}{
+		{
+			name: "unchanged",
+			old:  []CatalogRule{rule("release", "a.one")},
+			new:  []CatalogRule{rule("release", "a.one")},
+			want: []string{},
+		},
+		{
+			name: "added and removed",
+			old:  []CatalogRule{rule("release", "a.one"), rule("release", "a.gone")},
+			new:  []CatalogRule{rule("release", "a.two"), rule("release", "a.one"), rule("release", "a.new")},
+			want: []string{
+				"added release/a.new",
+				"added release/a.two",
+				"removed release/a.gone",
+			},
+		},
+		{
+			name: "matched by policy kind",
+			old:  []CatalogRule{rule("task", "a.one")},
+			new:  []CatalogRule{rule("release", "a.one")},
+			want: []string{
+				"added release/a.one",
+				"removed task/a.one",
+			},
+		},
+		{
+			name: "attributes",
+			old: []CatalogRule{changed("release", "a.one", func(r *CatalogRule) {
+				r.FailureMsg = "Failed"
+			})},
+			new: []CatalogRule{changed("release", "a.one", func(r *CatalogRule) {
+				r.Type = "warn"
+				r.EffectiveOn = "2025-05-01T00:00:00Z"
+				r.Title = "The rule"
+				r.Description = "The rule."
+			})},
+			want: []string{
+				"changed release/a.one: Rule type changed from `deny` to `warn`",
+				"changed release/a.one: Effective from set to `2025-05-01T00:00:00Z`",
+				"changed release/a.one: Title changed from `A rule` to `The rule`",
+				"changed release/a.one: Description changed",
+				"changed release/a.one: Failure message `Failed` removed",
+			},
+		},
+		{
+			name: "lists",
+			old: []CatalogRule{changed("release", "a.one", func(r *CatalogRule) {
+				r.Collections = []string{"minimal", "strict"}
+			})},
+			new: []CatalogRule{changed("release", "a.one", func(r *CatalogRule) {
+				r.Collections = []string{"strict", "redhat", "slsa3"}
+				r.DependsOn = []string{"a.two"}
+				r.RuleData = []string{"allowed"}
+			})},
+			want: []string{
+				"changed release/a.one: Added to collections `redhat`, `slsa3`, removed from collections `minimal`",
+				"changed release/a.one: Added to dependencies `a.two`",
+				"changed release/a.one: Added to rule data keys `allowed`",
+			},
+		},
+		{
+			name: "source is not significant",
+			old:  []CatalogRule{rule("release", "a.one")},
+			new: []CatalogRule{changed("release", "a.one", func(r *CatalogRule) {
+				r.Source = Source{File: "policy/release/b/b.rego", Row: 10}
+			})},
+			want: []string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			changelog := DiffCatalogs("v1", Catalog{Rules: c.old}, "v2", Catalog{Rules: c.new})
+
+			got := []string{}
+			for _, r := range changelog.Added {
+				got = append(got, fmt.Sprintf("added %s/%s", r.Origin, r.Code))
+			}
+			for _, r := range changelog.Removed {
+				got = append(got, fmt.Sprintf("removed %s/%s", r.Origin, r.Code))
+			}
+			for _, rc := range changelog.Changed {
+				for _, fc := range rc.Changes {
+					got = append(got, fmt.Sprintf("changed %s/%s: %s", rc.Origin, rc.Code, changeSummary(fc)))
+				}
+			}
+
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Errorf("got changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+
+			if changelog.Empty() != (len(c.want) == 0) {
+				t.Errorf("got empty %v for changes %v", changelog.Empty(), got)
+			}
+		})
+	}
+}
+
+func TestWriteChangelog(t *testing.T) {
+	changelog := Changelog{
+		From: "v1",
+		To:   "v2",
+		Added: []CatalogRule{
+			{Code: "a.new", Origin: "release", Title: "New rule", Type: "warn", EffectiveOn: "2025-05-01T00:00:00Z", Collections: []string{"minimal", "strict"}},
+		},
+		Removed: []CatalogRule{
+			{Code: "a.gone", Origin: "task", Title: "Gone rule", Type: "deny"},
+		},
+		Changed: []RuleChange{
+			{Code: "a.one", Origin: "release", Title: "Rule one", Changes: []FieldChange{{Field: "type", Old: "warn", New: "deny"}}},
+		},
+	}
+
+	cases := []struct {
+		name      string
+		changelog Changelog
+		format    string
+		want      string
+		err       string
+	}{
+		{
+			name:      "asciidoc",
+			changelog: changelog,
+			format:    "asciidoc",
+			want: `= What's changed
+
+Changes to the policy rules between ` + "`v1` and `v2`" + `.
+
+== New rules
+
+* ` + "`a.new`" + ` (release): New rule, a [rule-type-indicator warning]#WARNING# effective from ` + "`2025-05-01T00:00:00Z`, included in `minimal`, `strict`" + `
+
+== Removed rules
+
+* ` + "`a.gone`" + ` (task): Gone rule
+
+== Changed rules
+
+=== ` + "`a.one`" + ` (release): Rule one
+
+* Rule type changed from ` + "`warn` to `deny`",
+		},
+		{
+			name:      "markdown",
+			changelog: changelog,
+			format:    "markdown",
+			want: `# What's changed
+
+Changes to the policy rules between ` + "`v1` and `v2`" + `.
+
+## New rules
+
+* ` + "`a.new`" + ` (release): New rule, a **WARNING** effective from ` + "`2025-05-01T00:00:00Z`, included in `minimal`, `strict`" + `
+
+## Removed rules
+
+* ` + "`a.gone`" + ` (task): Gone rule
+
+## Changed rules
+
+### ` + "`a.one`" + ` (release): Rule one
+
+* Rule type changed from ` + "`warn` to `deny`",
+		},
+		{
+			name:      "empty",
+			changelog: Changelog{From: "v1", To: "v2"},
+			format:    "markdown",
+			want: `# What's changed
+
+Changes to the policy rules between ` + "`v1` and `v2`" + `.
+
+No rules were added, removed or changed.`,
+		},
+		{
+			name:      "json",
+			changelog: Changelog{From: "v1", To: "v2", Added: []CatalogRule{}, Removed: []CatalogRule{}, Changed: changelog.Changed},
+			format:    "json",
+			want: `{"from": "v1", "to": "v2", "added": [], "removed": [], "changed": [
+				{"code": "a.one", "origin": "release", "title": "Rule one", "changes": [
+					{"field": "type", "old": "warn", "new": "deny"}
+				]}
+			]}`,
+		},
+		{
+			name:      "unsupported",
+			changelog: changelog,
+			format:    "html",
+			err:       `unsupported changelog format "html", expecting one of: json, asciidoc, markdown`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got strings.Builder
+			err := WriteChangelog(&got, c.changelog, c.format)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Fatalf("expected error %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.format == "json" {
+				assertJSON(t, "changelog", got.String(), c.want)
+				return
+			}
+
+			if strings.TrimSpace(got.String()) != c.want {
+				t.Errorf("got changelog:\n%s\nwant:\n%s", got.String(), c.want)
+			}
+		})
+	}
+}
+
+func TestDiffCatalogsDeprecation(t *testing.T) {
+	deprecated := &Deprecation{Since: "2025-06-01T00:00:00Z", Reason: "Use b instead."}
+
+	cases := []struct {
+		name     string
+		old, new CatalogRule
+		want     []string
+	}{
+		{
+			name: "unchanged",
+			old:  CatalogRule{Deprecated: &Deprecation{Since: deprecated.Since, Reason: deprecated.Reason}},
+			new:  CatalogRule{Deprecated: deprecated},
+			want: []string{},
+		},
+		{
+			name: "deprecated",
+			new:  CatalogRule{Deprecated: deprecated, ReplacedBy: "a.b"},
+			want: []string{
+				"Replaced by set to `a.b`",
+				"Deprecated since `2025-06-01T00:00:00Z`: Use b instead.",
+			},
+		},
+		{
+			name: "no longer deprecated",
+			old:  CatalogRule{Deprecated: deprecated},
+			want: []string{"No longer deprecated"},
+		},
+		{
+			name: "reason changed",
+			old:  CatalogRule{Deprecated: &Deprecation{Since: deprecated.Since, Reason: "Old reason."}},
+			new:  CatalogRule{Deprecated: deprecated},
+			want: []string{"Deprecation changed, deprecated since `2025-06-01T00:00:00Z`: Use b instead."},
+		},
+		{
+			name: "replacement changed",
+			old:  CatalogRule{Deprecated: deprecated, ReplacedBy: "a.b"},
+			new:  CatalogRule{Deprecated: deprecated, ReplacedBy: "a.c"},
+			want: []string{"Replaced by changed from `a.b` to `a.c`"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.old.Code, c.new.Code = "a.a", "a.a"
+			changelog := DiffCatalogs("v1", Catalog{Rules: []CatalogRule{c.old}}, "v2", Catalog{Rules: []CatalogRule{c.new}})
+
+			got := []string{}
+			for _, rc := range changelog.Changed {
+				for _, fc := range rc.Changes {
+					got = append(got, changeSummary(fc))
+				}
+			}
+
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Errorf("got changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,433 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// Names of the convention checks
+const (
+	CheckParse               = "parse"
+	CheckRequiredAnnotations = "required-annotations"
+	CheckUniqueCode          = "unique-code"
+	CheckDependencyExists    = "dependency-exists"
+	CheckDependencyCycle     = "dependency-cycle"
+	CheckEffectiveOn         = "effective-on"
+	CheckDeprecated          = "deprecated"
+	CheckReplacementExists   = "replacement-exists"
+)
+
+// ConventionChecks describes each of the convention checks
+var ConventionChecks = map[string]string{
+	CheckParse:               "Rego files and their METADATA blocks can be parsed",
+	CheckRequiredAnnotations: "Policy rules have all the required annotations",
+	CheckUniqueCode:          "Rule codes, package name and short name, are unique within a policy kind",
+	CheckDependencyExists:    "Rules listed in custom.depends_on exist",
+	CheckDependencyCycle:     "Rules do not depend on each other in a cycle",
+	CheckEffectiveOn:         "The custom.effective_on annotation is a RFC3339 formatted date",
+	CheckDeprecated:          "The custom.deprecated annotation has a RFC3339 formatted date and a reason, custom.replaced_by is only set on deprecated rules",
+	CheckReplacementExists:   "Rules listed in custom.replaced_by exist",
+}
+
+// requiredAnnotations must be present on all policy rules
+var requiredAnnotations = []string{
+	"title",
+	"description",
+	"custom.short_name",
+	"custom.failure_msg",
+}
+
+// Violation is a breach of the policy authoring conventions
+type Violation struct {
+	// Check is the name of the convention check that found the violation
+	Check   string `json:"check"`
+	Message string `json:"message"`
+	// Code is the code of the offending rule, if known
+	Code     string `json:"code,omitempty"`
+	Location Source `json:"location"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("ERROR: %s at %s:%d", v.Message, v.Location.File, v.Location.Row)
+}
+
+// conventionRule is a policy rule considered by the convention checks
+type conventionRule struct {
+	key      ruleKey
+	ref      *ast.AnnotationsRef
+	location Source
+}
+
+// CheckConventions inspects the Rego directories and checks the annotations
+// of all policy rules, i.e. rules in Rego files under policy/ other than the
+// library, against the policy authoring conventions
+func CheckConventions(rego ...string) ([]Violation, error) {
+	roots, err := parseRoots(rego)
+	if err != nil {
+		return nil, err
+	}
+
+	o := origins{}
+	annotations, _, err := inspect(roots, o)
+	if err != nil {
+		var errs ast.Errors
+		if !errors.As(err, &errs) {
+			return nil, err
+		}
+
+		violations := make([]Violation, 0, len(errs))
+		for _, e := range errs {
+			v := Violation{Check: CheckParse, Message: e.Message}
+			if e.Location != nil {
+				// parse errors are located on disk, the other violations at
+				// the path the file is documented at
+				v.Location = Source{File: o.documented(e.Location.File), Row: e.Location.Row}
+			}
+			violations = append(violations, v)
+		}
+
+		return violations, nil
+	}
+
+	rules := make([]conventionRule, 0, 200)
+	for _, set := range annotations {
+		for _, ref := range set {
+			if ref.Annotations.Scope != "rule" || !isPolicyRule(ref) {
+				continue
+			}
+
+			r := conventionRule{
+				key: ruleKey{o.kind(ref.Annotations.Location.File), ruleCode(ref)},
+				ref: ref,
+			}
+			if l := ref.Annotations.Location; l != nil {
+				r.location = Source{File: l.File, Row: l.Row}
+			}
+			rules = append(rules, r)
+		}
+	}
+
+	violations := make([]Violation, 0, 10)
+	violations = append(violations, checkRequiredAnnotations(rules)...)
+	violations = append(violations, checkUniqueCodes(rules)...)
+	violations = append(violations, checkDependencies(rules)...)
+	violations = append(violations, checkEffectiveOn(rules)...)
+	violations = append(violations, checkDeprecations(rules)...)
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].Location.File != violations[j].Location.File {
+			return violations[i].Location.File < violations[j].Location.File
+		}
+		return violations[i].Location.Row < violations[j].Location.Row
+	})
+
+	return violations, nil
+}
+
+// isPolicyRule returns true for rules defined in the policy directory outside
+// of the library
+func isPolicyRule(ref *ast.AnnotationsRef) bool {
+	f := ref.Location.File
+	return strings.HasPrefix(f, "policy/") && !strings.HasPrefix(f, "policy/lib/")
+}
+
+// ruleCode returns the package name and the short name of the rule, or just
+// the package name if the rule has no short name
+func ruleCode(ref *ast.AnnotationsRef) string {
+	path := ref.GetPackage().Path
+	name := strings.Trim(path[len(path)-1].String(), `"`)
+
+	if sn, ok := ref.Annotations.Custom["short_name"].(string); ok {
+		return name + "." + sn
+	}
+
+	return name
+}
+
+func checkRequiredAnnotations(rules []conventionRule) []Violation {
+	violations := make([]Violation, 0, 5)
+	for _, r := range rules {
+		a := r.ref.Annotations
+		declared := map[string]bool{
+			"title":       a.Title != "",
+			"description": a.Description != "",
+		}
+		for k, v := range a.Custom {
+			declared["custom."+k] = v != nil
+		}
+
+		missing := make([]string, 0, len(requiredAnnotations))
+		for _, req := range requiredAnnotations {
+			if !declared[req] {
+				missing = append(missing, req)
+			}
+		}
+
+		if len(missing) > 0 {
+			violations = append(violations, Violation{
+				Check:    CheckRequiredAnnotations,
+				Message:  fmt.Sprintf("Missing annotation(s) %s", strings.Join(missing, ", ")),
+				Code:     r.key.code,
+				Location: r.location,
+			})
+		}
+	}
+
+	return violations
+}
+
+func checkUniqueCodes(rules []conventionRule) []Violation {
+	counts := map[ruleKey]int{}
+	for _, r := range rules {
+		counts[r.key]++
+	}
+
+	violations := make([]Violation, 0, 5)
+	for _, r := range rules {
+		if counts[r.key] > 1 {
+			violations = append(violations, Violation{
+				Check:    CheckUniqueCode,
+				Message:  fmt.Sprintf("Found non-unique code %q in the %s policy", r.key.code, r.key.origin),
+				Code:     r.key.code,
+				Location: r.location,
+			})
+		}
+	}
+
+	return violations
+}
+
+// checkDependencies checks that the rules listed in custom.depends_on exist,
+// in any of the policy kinds, and that there are no dependency cycles. As for
+// the documentation, a dependency is resolved to the rule from the same policy
+// kind if there is one.
+func checkDependencies(rules []conventionRule) []Violation {
+	byCode := map[string][]ruleKey{}
+	byKey := map[ruleKey]conventionRule{}
+	for _, r := range rules {
+		byCode[r.key.code] = append(byCode[r.key.code], r.key)
+		byKey[r.key] = r
+	}
+
+	violations := make([]Violation, 0, 5)
+	edges := map[ruleKey][]ruleKey{}
+	for _, r := range rules {
+		for _, dep := range customStrings(r.ref.Annotations, "depends_on") {
+			candidates := byCode[dep]
+			if len(candidates) == 0 {
+				violations = append(violations, Violation{
+					Check:    CheckDependencyExists,
+					Message:  fmt.Sprintf("Missing dependency rule %q", dep),
+					Code:     r.key.code,
+					Location: r.location,
+				})
+				continue
+			}
+
+			to := candidates[0]
+			for _, c := range candidates {
+				if c.origin == r.key.origin {
+					to = c
+				}
+			}
+			edges[r.key] = append(edges[r.key], to)
+		}
+	}
+
+	for _, cycle := range cycles(edges) {
+		r := byKey[cycle[0]]
+		codes := make([]string, 0, len(cycle)+1)
+		for _, k := range cycle {
+			codes = append(codes, k.code)
+		}
+		codes = append(codes, cycle[0].code)
+
+		violations = append(violations, Violation{
+			Check:    CheckDependencyCycle,
+			Message:  fmt.Sprintf("Dependency cycle %s", strings.Join(codes, " -> ")),
+			Code:     r.key.code,
+			Location: r.location,
+		})
+	}
+
+	return violations
+}
+
+// cycles returns each of the cycles found in the graph once, starting with the
+// smallest rule in the cycle
+func cycles(edges map[ruleKey][]ruleKey) [][]ruleKey {
+	less := func(a, b ruleKey) bool {
+		return a.origin+"/"+a.code < b.origin+"/"+b.code
+	}
+
+	nodes := make([]ruleKey, 0, len(edges))
+	for k := range edges {
+		nodes = append(nodes, k)
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return less(nodes[i], nodes[j])
+	})
+
+	found := [][]ruleKey{}
+	seen := map[string]bool{}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[ruleKey]int{}
+	stack := []ruleKey{}
+
+	var visit func(k ruleKey)
+	visit = func(k ruleKey) {
+		state[k] = visiting
+		stack = append(stack, k)
+
+		for _, next := range edges[k] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case visiting:
+				start := 0
+				for i, s := range stack {
+					if s == next {
+						start = i
+					}
+				}
+				cycle := append([]ruleKey{}, stack[start:]...)
+
+				// rotate so that the cycle starts with its smallest rule
+				min := 0
+				for i := range cycle {
+					if less(cycle[i], cycle[min]) {
+						min = i
+					}
+				}
+				cycle = append(cycle[min:], cycle[:min]...)
+
+				id := fmt.Sprint(cycle)
+				if !seen[id] {
+					seen[id] = true
+					found = append(found, cycle)
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[k] = done
+	}
+
+	for _, k := range nodes {
+		if state[k] == unvisited {
+			visit(k)
+		}
+	}
+
+	return found
+}
+
+func checkEffectiveOn(rules []conventionRule) []Violation {
+	violations := make([]Violation, 0, 5)
+	for _, r := range rules {
+		v, ok := r.ref.Annotations.Custom["effective_on"]
+		if !ok {
+			continue
+		}
+
+		s, isString := v.(string)
+		if _, err := time.Parse(time.RFC3339, s); !isString || err != nil {
+			violations = append(violations, Violation{
+				Check:    CheckEffectiveOn,
+				Message:  fmt.Sprintf("wrong syntax of effective_on value %q", fmt.Sprint(v)),
+				Code:     r.key.code,
+				Location: r.location,
+			})
+		}
+	}
+
+	return violations
+}
+
+// checkDeprecations checks the custom.deprecated annotation and that the
+// rules listed in custom.replaced_by exist, in any of the policy kinds, and
+// are not the deprecated rule itself
+func checkDeprecations(rules []conventionRule) []Violation {
+	byCode := map[string]bool{}
+	for _, r := range rules {
+		byCode[r.key.code] = true
+	}
+
+	violations := make([]Violation, 0, 5)
+	violation := func(r conventionRule, check, format string, args ...any) {
+		violations = append(violations, Violation{
+			Check:    check,
+			Message:  fmt.Sprintf(format, args...),
+			Code:     r.key.code,
+			Location: r.location,
+		})
+	}
+
+	for _, r := range rules {
+		a := r.ref.Annotations
+		replacement, replaced := a.Custom["replaced_by"]
+
+		v, deprecated := a.Custom["deprecated"]
+		if !deprecated {
+			if replaced {
+				violation(r, CheckDeprecated, "custom.replaced_by set on a rule that is not deprecated")
+			}
+			continue
+		}
+
+		if _, ok := v.(map[string]any); !ok {
+			violation(r, CheckDeprecated, "wrong syntax of deprecated value %q, expecting an object with the since and reason keys", fmt.Sprint(v))
+			continue
+		}
+
+		d := deprecation(a)
+		if _, err := time.Parse(time.RFC3339, d.Since); err != nil {
+			violation(r, CheckDeprecated, "wrong syntax of deprecated.since value %q", d.Since)
+		}
+		if d.Reason == "" {
+			violation(r, CheckDeprecated, "Missing annotation custom.deprecated.reason")
+		}
+
+		if !replaced {
+			continue
+		}
+
+		code, ok := replacement.(string)
+		switch {
+		case !ok:
+			violation(r, CheckReplacementExists, "wrong syntax of replaced_by value %q, expecting a rule code", fmt.Sprint(replacement))
+		case code == r.key.code:
+			violation(r, CheckReplacementExists, "Rule %q is replaced by itself", code)
+		case !byCode[code]:
+			violation(r, CheckReplacementExists, "Missing replacement rule %q", code)
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,232 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// conventionsModule returns a Rego module of the package with a deny rule
+// for each of the given custom annotations, the title and the description
+// are omitted from the rules with the custom annotation omit: true
+func conventionsModule(pkg string, rules ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\nimport rego.v1\n", pkg)
+	for _, custom := range rules {
+		b.WriteString("\n# METADATA\n")
+		if !strings.Contains(custom, "omit: true") {
+			b.WriteString("# title: A rule\n# description: A rule.\n")
+		}
+		b.WriteString("# custom:\n")
+		for _, l := range strings.Split(custom, "\n") {
+			fmt.Fprintf(&b, "#   %s\n", l)
+		}
+		b.WriteString("deny contains \"x\" if {\n\ttrue\n}\n")
+	}
+
+	return b.String()
+}
+
+func TestCheckConventions(t *testing.T) {
+	cases := []struct {
+		name  string
+		files map[string]string
+		// want holds the check, code and message of each violation
+		want []string
+	}{
+		{
+			name: "valid rules",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a",
+					"short_name: one\nfailure_msg: failed",
+					"short_name: two\nfailure_msg: failed\ndepends_on:\n- a.one\neffective_on: 2025-05-01T00:00:00Z"),
+			},
+		},
+		{
+			name: "missing annotations",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a",
+					"short_name: one\nomit: true",
+					"failure_msg: failed"),
+			},
+			want: []string{
+				"required-annotations a.one Missing annotation(s) title, description, custom.failure_msg",
+				"required-annotations a Missing annotation(s) custom.short_name",
+			},
+		},
+		{
+			name: "library rules are not checked",
+			files: map[string]string{
+				"policy/lib/a/a.rego": conventionsModule("lib.a", "omit: true"),
+			},
+		},
+		{
+			name: "non-unique code",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a",
+					"short_name: one\nfailure_msg: failed",
+					"short_name: one\nfailure_msg: failed again"),
+			},
+			want: []string{
+				`unique-code a.one Found non-unique code "a.one" in the release policy`,
+				`unique-code a.one Found non-unique code "a.one" in the release policy`,
+			},
+		},
+		{
+			name: "same code in different policy kinds",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a", "short_name: one\nfailure_msg: failed"),
+				"policy/task/a/a.rego":    conventionsModule("a", "short_name: one\nfailure_msg: failed"),
+			},
+		},
+		{
+			name: "dependency in another policy kind",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a", "short_name: one\nfailure_msg: failed\ndepends_on:\n- b.two"),
+				"policy/task/b/b.rego":    conventionsModule("b", "short_name: two\nfailure_msg: failed"),
+			},
+		},
+		{
+			name: "missing dependency",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a", "short_name: one\nfailure_msg: failed\ndepends_on:\n- b.two"),
+			},
+			want: []string{
+				`dependency-exists a.one Missing dependency rule "b.two"`,
+			},
+		},
+		{
+			name: "dependency cycle",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a",
+					"short_name: two\nfailure_msg: failed\ndepends_on:\n- a.three",
+					"short_name: three\nfailure_msg: failed\ndepends_on:\n- a.one",
+					"short_name: one\nfailure_msg: failed\ndepends_on:\n- a.two"),
+			},
+			want: []string{
+				"dependency-cycle a.one Dependency cycle a.one -> a.two -> a.three -> a.one",
+			},
+		},
+		{
+			name: "rule depending on itself",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a", "short_name: one\nfailure_msg: failed\ndepends_on:\n- a.one"),
+			},
+			want: []string{
+				"dependency-cycle a.one Dependency cycle a.one -> a.one",
+			},
+		},
+		{
+			name: "effective_on",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a",
+					"short_name: one\nfailure_msg: failed\neffective_on: 2025-05-01T00:00:00Z",
+					"short_name: two\nfailure_msg: failed\neffective_on: 2025-5-01T00:00:00Z",
+					"short_name: three\nfailure_msg: failed\neffective_on: 2025-07-01",
+					"short_name: four\nfailure_msg: failed\neffective_on: 1"),
+			},
+			want: []string{
+				`effective-on a.two wrong syntax of effective_on value "2025-5-01T00:00:00Z"`,
+				`effective-on a.three wrong syntax of effective_on value "2025-07-01"`,
+				`effective-on a.four wrong syntax of effective_on value "1"`,
+			},
+		},
+		{
+			name: "deprecation",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a",
+					"short_name: one\nfailure_msg: failed\ndeprecated:\n  since: 2025-05-01T00:00:00Z\n  reason: Replaced\nreplaced_by: a.two",
+					"short_name: two\nfailure_msg: failed\nreplaced_by: a.one",
+					"short_name: three\nfailure_msg: failed\ndeprecated:\n  since: 2025-05-01\nreplaced_by: a.four"),
+			},
+			want: []string{
+				"deprecated a.two custom.replaced_by set on a rule that is not deprecated",
+				`deprecated a.three wrong syntax of deprecated.since value "2025-05-01"`,
+				"deprecated a.three Missing annotation custom.deprecated.reason",
+				`replacement-exists a.three Missing replacement rule "a.four"`,
+			},
+		},
+		{
+			name: "parse error",
+			files: map[string]string{
+				"policy/release/a/a.rego": "package a\n\ndeny if {",
+			},
+			want: []string{
+				"parse  unexpected eof token",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range c.files {
+				writeFile(t, dir, name, content)
+			}
+
+			violations, err := CheckConventions(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(violations))
+			for _, v := range violations {
+				got = append(got, fmt.Sprintf("%s %s %s", v.Check, v.Code, v.Message))
+
+				// parse errors are located like the other violations
+				if v.Location.File != "policy/release/a/a.rego" || v.Location.Row == 0 {
+					t.Errorf("unexpected location %s:%d of %v", v.Location.File, v.Location.Row, v)
+				}
+			}
+
+			if len(got) != len(c.want) {
+				t.Fatalf("got violations:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+
+			for i := range got {
+				if !strings.HasPrefix(got[i], c.want[i]) {
+					t.Errorf("got violation %q, want %q", got[i], c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckConventionsOrder(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "policy/release/b/b.rego", conventionsModule("b", "short_name: one\nomit: true"))
+	writeFile(t, dir, "policy/release/a/a.rego", conventionsModule("a",
+		"short_name: one\nomit: true",
+		"short_name: two\nomit: true"))
+
+	violations, err := CheckConventions(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make([]string, 0, len(violations))
+	for _, v := range violations {
+		got = append(got, v.Code)
+	}
+
+	if want := []string{"a.one", "a.two", "b.one"}; !slices.Equal(got, want) {
+		t.Errorf("got violations of %v, want %v, sorted by location", got, want)
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// coverageReport is the subset of the JSON report produced by
+// `opa test --coverage --format json` holding the rows covered, and not
+// covered, by the tests in each file
+type coverageReport struct {
+	Files map[string]struct {
+		Covered    []coverageRange `json:"covered"`
+		NotCovered []coverageRange `json:"not_covered"`
+	} `json:"files"`
+}
+
+type coverageRange struct {
+	Start struct {
+		Row int `json:"row"`
+	} `json:"start"`
+	End struct {
+		Row int `json:"row"`
+	} `json:"end"`
+}
+
+// coverageLines is the number of lines covered, and not covered, by tests
+type coverageLines struct {
+	Covered    int
+	NotCovered int
+}
+
+// Lines returns the number of lines to cover
+func (l coverageLines) Lines() int {
+	return l.Covered + l.NotCovered
+}
+
+// Percent returns the percentage of the lines covered, 100 if there are no
+// lines to cover
+func (l coverageLines) Percent() float64 {
+	if l.Lines() == 0 {
+		return 100
+	}
+
+	return float64(l.Covered) * 100 / float64(l.Lines())
+}
+
+// Color returns the color of the coverage badge
+func (l coverageLines) Color() string {
+	switch p := l.Percent(); {
+	case p == 100:
+		return "brightgreen"
+	case p >= 90:
+		return "green"
+	case p >= 75:
+		return "yellow"
+	default:
+		return "red"
+	}
+}
+
+func (l coverageLines) add(o coverageLines) coverageLines {
+	return coverageLines{Covered: l.Covered + o.Covered, NotCovered: l.NotCovered + o.NotCovered}
+}
+
+// coverage holds the test coverage of the documented packages and rules
+type coverage struct {
+	lines map[*ast.Annotations]coverageLines
+}
+
+// Of returns the test coverage of the package or of the rule with the given
+// annotations, nil if no coverage report was provided or if the report has no
+// lines of the package or the rule
+func (c *coverage) Of(a *ast.Annotations) *coverageLines {
+	if c == nil {
+		return nil
+	}
+
+	l, ok := c.lines[a]
+	if !ok || l.Lines() == 0 {
+		return nil
+	}
+
+	return &l
+}
+
+// Coverage is the test coverage of the documented packages
+type Coverage struct {
+	Packages []PackageCoverage `json:"packages"`
+}
+
+// PackageCoverage is the test coverage of all the lines in the files of a
+// package, the documented rules as well as the helper rules and functions, and
+// of the lines of each of its documented rules
+type PackageCoverage struct {
+	// Name is the policy kind the package comes from and the package name,
+	// e.g. release.tasks
+	Name       string         `json:"name"`
+	Covered    int            `json:"covered_lines"`
+	NotCovered int            `json:"not_covered_lines"`
+	Coverage   float64        `json:"coverage"`
+	Rules      []RuleCoverage `json:"rules"`
+}
+
+// RuleCoverage is the test coverage of the lines of a rule
+type RuleCoverage struct {
+	// Code is the package name and the rule's short name, e.g. tasks.required_tasks_found
+	Code       string  `json:"code"`
+	Covered    int     `json:"covered_lines"`
+	NotCovered int     `json:"not_covered_lines"`
+	Coverage   float64 `json:"coverage"`
+	Source     Source  `json:"source"`
+}
+
+// LoadCoverage inspects the Rego directories and maps the lines in the OPA
+// coverage report, `opa test --coverage --format json`, to the packages and
+// the rules of the given policy kinds, or of the discovered kinds if none are
+// given
+func LoadCoverage(report string, kinds []Kind, rego ...string) (Coverage, error) {
+	m, err := load(kinds, rego)
+	if err != nil {
+		return Coverage{}, err
+	}
+
+	c, err := mapCoverage(report, m)
+	if err != nil {
+		return Coverage{}, err
+	}
+
+	packages := make([]PackageCoverage, 0, 50)
+	for _, d := range m.docs {
+		for _, p := range *d.Packages {
+			name := packageName(&p)
+			l := c.lines[p.Annotations]
+			pc := PackageCoverage{
+				Name:       p.Origin + "." + name,
+				Covered:    l.Covered,
+				NotCovered: l.NotCovered,
+				Coverage:   l.Percent(),
+				Rules:      make([]RuleCoverage, 0, len(*p.Rules)),
+			}
+
+			for _, a := range *p.Rules {
+				l := c.lines[a]
+				rc := RuleCoverage{
+					Code:       fmt.Sprintf("%s.%s", name, a.Custom["short_name"]),
+					Covered:    l.Covered,
+					NotCovered: l.NotCovered,
+					Coverage:   l.Percent(),
+				}
+				if a.Location != nil {
+					rc.Source = Source{File: a.Location.File, Row: a.Location.Row}
+				}
+				pc.Rules = append(pc.Rules, rc)
+			}
+
+			packages = append(packages, pc)
+		}
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Name < packages[j].Name
+	})
+
+	return Coverage{Packages: packages}, nil
+}
+
+// mapCoverage reads the OPA coverage report and counts the lines covered, and
+// not covered, in the files of each documented package, and in the lines of
+// each documented rule, i.e. from the rule's head to the end of its body. The
+// package figure counts every line of its files the report holds, including
+// the helper rules and functions, so it can be lower than the figure of each
+// of its documented rules.
+func mapCoverage(report string, m *model) (*coverage, error) {
+	data, err := os.ReadFile(report)
+	if err != nil {
+		return nil, fmt.Errorf("reading the coverage report: %w", err)
+	}
+
+	var r coverageReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("parsing the coverage report %q: %w", report, err)
+	}
+
+	// the rows of each file, true when covered, the files are keyed by
+	// their cleaned path so they can be matched to the location of the
+	// annotations regardless of how opa test was given the directories
+	rows := map[string]map[int]bool{}
+	for file, f := range r.Files {
+		file = strings.TrimPrefix(path.Clean(file), "./")
+		rows[file] = map[int]bool{}
+		for _, set := range []struct {
+			ranges  []coverageRange
+			covered bool
+		}{{f.NotCovered, false}, {f.Covered, true}} {
+			for _, rng := range set.ranges {
+				for row := rng.Start.Row; row <= rng.End.Row; row++ {
+					rows[file][row] = set.covered
+				}
+			}
+		}
+	}
+
+	count := func(file string, from, to int) (coverageLines, error) {
+		var l coverageLines
+		key, err := reportFile(rows, file)
+		if err != nil {
+			return l, err
+		}
+
+		for row, covered := range rows[key] {
+			if row < from || row > to {
+				continue
+			}
+			if covered {
+				l.Covered++
+			} else {
+				l.NotCovered++
+			}
+		}
+
+		return l, nil
+	}
+
+	files := map[string][]string{}
+	for _, mod := range m.modules {
+		p := mod.Package.Path.String()
+		files[p] = append(files[p], mod.Package.Location.File)
+	}
+
+	c := coverage{lines: map[*ast.Annotations]coverageLines{}}
+	for _, set := range m.annotations {
+		for _, ref := range set {
+			switch ref.Annotations.Scope {
+			case "package", "subpackages":
+				var l coverageLines
+				for _, f := range files[ref.GetPackage().Path.String()] {
+					fl, err := count(f, 0, math.MaxInt)
+					if err != nil {
+						return nil, err
+					}
+					l = l.add(fl)
+				}
+				c.lines[ref.Annotations] = l
+			case "rule":
+				rule := ref.GetRule()
+				if rule == nil || rule.Location == nil {
+					continue
+				}
+
+				from := rule.Location.Row
+				to := from + strings.Count(string(rule.Location.Text), "\n")
+				l, err := count(rule.Location.File, from, to)
+				if err != nil {
+					return nil, err
+				}
+				c.lines[ref.Annotations] = l
+			}
+		}
+	}
+
+	return &c, nil
+}
+
+// reportFile returns the key of the file in the coverage report, the report
+// can hold paths with a prefix, e.g. when opa test was run in a parent
+// directory. It is an error if more than one path in the report ends with the
+// file's path, as the coverage of either could be reported.
+func reportFile(rows map[string]map[int]bool, file string) (string, error) {
+	if _, ok := rows[file]; ok {
+		return file, nil
+	}
+
+	matches := make([]string, 0, 1)
+	for k := range rows {
+		if strings.HasSuffix(k, "/"+file) {
+			matches = append(matches, k)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return file, nil
+	case 1:
+		return matches[0], nil
+	}
+
+	sort.Strings(matches)
+
+	return "", fmt.Errorf("the coverage report holds more than one file matching %q: %s", file, strings.Join(matches, ", "))
+}
@@ -0,0 +1,146 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestReportFile(t *testing.T) {
+	rows := map[string]map[int]bool{
+		"policy/release/a/a.rego":     {},
+		"x/policy/release/b/b.rego":   {},
+		"x/policy/release/c/c.rego":   {},
+		"y/x/policy/release/c/c.rego": {},
+	}
+
+	cases := []struct {
+		file string
+		want string
+		err  bool
+	}{
+		{file: "policy/release/a/a.rego", want: "policy/release/a/a.rego"},
+		{file: "policy/release/b/b.rego", want: "x/policy/release/b/b.rego"},
+		{file: "policy/release/c/c.rego", err: true},
+		{file: "policy/release/d/d.rego", want: "policy/release/d/d.rego"},
+	}
+
+	for _, c := range cases {
+		got, err := reportFile(rows, c.file)
+		if c.err {
+			if err == nil {
+				t.Errorf("expected an error for the ambiguous %s, got %q", c.file, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", c.file, err)
+		} else if got != c.want {
+			t.Errorf("got %q for %s, want %q", got, c.file, c.want)
+		}
+	}
+}
+
+func TestCoverageOf(t *testing.T) {
+	covered, empty, unmapped := &ast.Annotations{}, &ast.Annotations{}, &ast.Annotations{}
+	c := &coverage{lines: map[*ast.Annotations]coverageLines{
+		covered: {Covered: 3, NotCovered: 1},
+		empty:   {},
+	}}
+
+	if l := c.Of(covered); l == nil || l.Percent() != 75 {
+		t.Errorf("got coverage %v, want 75%%", l)
+	}
+
+	if l := c.Of(empty); l != nil {
+		t.Errorf("got coverage %v without any lines, want none", l)
+	}
+
+	if l := c.Of(unmapped); l != nil {
+		t.Errorf("got coverage %v of a rule not in the report, want none", l)
+	}
+
+	if l := (*coverage)(nil).Of(covered); l != nil {
+		t.Errorf("got coverage %v without a report, want none", l)
+	}
+}
+
+func TestMapCoverage(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "policy/release/a/a.rego", `# METADATA
+# title: A package
+package a
+
+import rego.v1
+
+# METADATA
+# title: A rule
+# custom:
+#   short_name: a_rule
+deny contains "a" if {
+	_helper
+}
+
+_helper if {
+	true
+}
+`)
+	// row 12 of the rule and row 16 of the helper are not covered
+	writeFile(t, dir, "coverage.json", `{"files": {"policy/release/a/a.rego": {
+	"covered": [{"start": {"row": 11}, "end": {"row": 11}}, {"start": {"row": 15}, "end": {"row": 15}}],
+	"not_covered": [{"start": {"row": 12}, "end": {"row": 12}}, {"start": {"row": 16}, "end": {"row": 16}}]
+}}}`)
+
+	annotations, modules, err := inspect([]root{{Path: dir}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := mapCoverage(filepath.Join(dir, "coverage.json"), &model{annotations: annotations, modules: modules})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checked := 0
+	for _, set := range annotations {
+		for _, ref := range set {
+			var want coverageLines
+			switch ref.Annotations.Scope {
+			case "package", "subpackages":
+				// the helper is counted in the package
+				want = coverageLines{Covered: 2, NotCovered: 2}
+			case "rule":
+				want = coverageLines{Covered: 1, NotCovered: 1}
+			default:
+				continue
+			}
+
+			if got := c.Of(ref.Annotations); got == nil || *got != want {
+				t.Errorf("got coverage %v of the %s, want %v", got, ref.Annotations.Scope, want)
+			}
+			checked++
+		}
+	}
+
+	if checked != 2 {
+		t.Errorf("expected the coverage of the package and the rule, checked %d", checked)
+	}
+}
@@ -0,0 +1,622 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/open-policy-agent/opa/storage/inmem"
+	"github.com/open-policy-agent/opa/topdown"
+)
+
+const (
+	// testSuffix is the suffix of the Rego files holding the tests
+	testSuffix = "_test.rego"
+	// testPrefix is the prefix of the names of the test rules
+	testPrefix = "test_"
+	// expandDepth limits how deep references to variables and constant rules
+	// are followed when expanding the terms of a test
+	expandDepth = 10
+)
+
+// assertions are the helpers the tests use to compare the results of a rule
+// with the expected results, the expected results are in either argument
+var assertions = map[string]bool{
+	"data.lib.assert_equal_results":                true,
+	"data.lib.assert_equal_results_no_collections": true,
+}
+
+// example is a worked example of a failing rule taken from its tests
+type example struct {
+	// Test is the name of the test the example is taken from
+	Test string
+	// File is the path of the test file, relative to the Rego directory
+	File string
+	// Row is the row of the assertion in the test file
+	Row int
+	// Input is the JSON encoded input document causing the rule to fail
+	Input string
+	// Data is the JSON encoded data, e.g. rule data, the test sets, empty if
+	// the test does not set any
+	Data string
+	// Message is the message of the result reported by the rule
+	Message string
+	// results is the number of results the test expects, examples where only
+	// the documented rule reports a result are preferred
+	results int
+}
+
+// size is used to find the minimal example, the one with the fewest results
+// and the smallest input
+func (e example) size() (int, int) {
+	return e.results, len(e.Input) + len(e.Data)
+}
+
+// examples holds the worked example of each rule
+type examples struct {
+	rules map[*ast.Annotations]*example
+}
+
+// Of returns the example for the rule with the given annotations, nil if none
+// of the rule's tests provide one
+func (e *examples) Of(a *ast.Annotations) *example {
+	if e == nil {
+		return nil
+	}
+
+	return e.rules[a]
+}
+
+// exampleKey identifies a rule by the path of its package and its code, as
+// asserted in the tests
+type exampleKey struct {
+	pkg  string
+	code string
+}
+
+// extractExamples parses the tests found in the Rego directories and links the
+// tests to the rules they assert using the results they expect, i.e. the code
+// of the rule, passed to lib.assert_equal_results when the rule's package is
+// evaluated with the input set by `with input as`. Only inputs, data and
+// messages that can be determined statically, literals, variables assigned a
+// literal and constant rules, are considered. For each rule the minimal
+// example is kept.
+func extractExamples(modules []*ast.Module, annotations []ast.FlatAnnotationsRefSet, roots []root, o origins) (*examples, error) {
+	tests, err := inspectTests(roots, o)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := map[string][]*ast.Rule{}
+	for _, ms := range [][]*ast.Module{modules, tests} {
+		for _, m := range ms {
+			for _, r := range m.Rules {
+				p := r.Ref().GroundPrefix().String()
+				rules[p] = append(rules[p], r)
+			}
+		}
+	}
+
+	found := map[exampleKey]*example{}
+	for _, m := range tests {
+		for _, r := range m.Rules {
+			if !strings.HasPrefix(r.Head.Name.String(), testPrefix) {
+				continue
+			}
+
+			for key, e := range testExamples(rules, r) {
+				if current, ok := found[key]; !ok || smaller(e, current) {
+					found[key] = e
+				}
+			}
+		}
+	}
+
+	ex := examples{rules: map[*ast.Annotations]*example{}}
+	for _, set := range annotations {
+		for _, ref := range set {
+			if ref.Annotations.Scope != "rule" {
+				continue
+			}
+
+			rule := ref.GetRule()
+			shortName, ok := ref.Annotations.Custom["short_name"].(string)
+			if rule == nil || !ok {
+				continue
+			}
+
+			path := rule.Module.Package.Path
+			code := fmt.Sprintf("%s.%s", strings.Trim(path[len(path)-1].String(), `"`), shortName)
+			if e, ok := found[exampleKey{pkg: path.String(), code: code}]; ok {
+				ex.rules[ref.Annotations] = e
+			}
+		}
+	}
+
+	return &ex, nil
+}
+
+// smaller reports whether the example a is smaller than the example b, the
+// name of the test is used to keep the choice stable
+func smaller(a, b *example) bool {
+	ar, as := a.size()
+	br, bs := b.size()
+	if ar != br {
+		return ar < br
+	}
+	if as != bs {
+		return as < bs
+	}
+
+	return a.Test < b.Test
+}
+
+// inspectTests parses all test Rego files found under the given directories
+func inspectTests(roots []root, o origins) ([]*ast.Module, error) {
+	modules := make([]*ast.Module, 0, 100)
+	var problems ast.Errors
+
+	for _, r := range roots {
+		fileSystem := os.DirFS(r.Path)
+		err := fs.WalkDir(fileSystem, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !strings.HasSuffix(path, testSuffix) {
+				return nil
+			}
+
+			data, err := fs.ReadFile(fileSystem, path)
+			if err != nil {
+				return err
+			}
+
+			mod, err := ast.ParseModule(r.file(path), string(data))
+			if err != nil {
+				problems = append(problems, locate(r, path, err)...)
+				return nil
+			}
+
+			modules = append(modules, mod)
+			o.add(r.file(path), r, path)
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("inspecting tests in %q: %w", r.Path, err)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, problems
+	}
+
+	return modules, nil
+}
+
+// testExamples returns the examples found in the assertions of the test rule
+// keyed by the rule they are an example of
+func testExamples(rules map[string][]*ast.Rule, test *ast.Rule) map[exampleKey]*example {
+	locals := localTerms(test)
+	found := map[exampleKey]*example{}
+
+	for _, e := range test.Body {
+		if !e.IsCall() {
+			continue
+		}
+
+		if !assertions[qualify(test, e.Operator()).String()] {
+			continue
+		}
+
+		operands := e.Operands()
+		if len(operands) != 2 {
+			continue
+		}
+
+		for i, evaluated := range operands {
+			ref, ok := evaluated.Value.(ast.Ref)
+			if !ok || len(ref) < 2 {
+				continue
+			}
+
+			pkg := qualify(test, ref)
+			pkg = pkg[:len(pkg)-1]
+
+			expected, ok := evaluate(expand(rules, test, locals, operands[1-i], expandDepth))
+			if !ok {
+				continue
+			}
+
+			input, data, ok := modifiers(rules, test, locals, e.With)
+			if !ok {
+				continue
+			}
+
+			results := expectedResults(expected)
+			for code, msg := range results {
+				found[exampleKey{pkg: pkg.String(), code: code}] = &example{
+					Test:    test.Head.Name.String(),
+					File:    e.Location.File,
+					Row:     e.Location.Row,
+					Input:   input,
+					Data:    data,
+					Message: msg,
+					results: len(results),
+				}
+			}
+		}
+	}
+
+	return found
+}
+
+// expectedResults returns the message of each expected result keyed by the
+// code of the result. The results are in a set, or in an array when they were
+// evaluated.
+func expectedResults(expected ast.Value) map[string]string {
+	var each func(func(*ast.Term))
+	switch v := expected.(type) {
+	case ast.Set:
+		each = v.Foreach
+	case *ast.Array:
+		each = v.Foreach
+	default:
+		return nil
+	}
+
+	results := map[string]string{}
+	each(func(t *ast.Term) {
+		obj, ok := t.Value.(ast.Object)
+		if !ok {
+			return
+		}
+
+		code, ok := stringValue(obj.Get(ast.StringTerm("code")))
+		if !ok {
+			return
+		}
+
+		msg, _ := stringValue(obj.Get(ast.StringTerm("msg")))
+		results[code] = msg
+	})
+
+	return results
+}
+
+func stringValue(t *ast.Term) (string, bool) {
+	if t == nil {
+		return "", false
+	}
+
+	s, ok := t.Value.(ast.String)
+
+	return string(s), ok
+}
+
+// modifiers returns the JSON encoded input and data set by the with modifiers
+// of the assertion. The example is not usable, i.e. false is returned, if any
+// of the values can not be determined statically, or if a function is
+// replaced, as the input alone would not cause the failure.
+func modifiers(rules map[string][]*ast.Rule, r *ast.Rule, locals map[ast.Var]*ast.Term, with []*ast.With) (string, string, bool) {
+	var input, data any
+	for _, w := range with {
+		target, ok := w.Target.Value.(ast.Ref)
+		if !ok {
+			return "", "", false
+		}
+
+		var doc *any
+		switch {
+		case target.HasPrefix(ast.InputRootRef):
+			doc = &input
+		case target.HasPrefix(ast.DefaultRootRef):
+			doc = &data
+		default:
+			return "", "", false
+		}
+
+		v, ok := evaluate(expand(rules, r, locals, w.Value, expandDepth))
+		if !ok {
+			return "", "", false
+		}
+
+		j, err := ast.JSON(v)
+		if err != nil {
+			return "", "", false
+		}
+
+		if *doc, ok = place(*doc, target[1:], j); !ok {
+			return "", "", false
+		}
+	}
+
+	// the rule fails for the data alone, e.g. when the rule data is missing
+	if input == nil {
+		input = map[string]any{}
+	}
+
+	i, err := json.MarshalIndent(input, "", "  ")
+	if err != nil {
+		return "", "", false
+	}
+
+	if data == nil {
+		return string(i), "", true
+	}
+
+	d, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return "", "", false
+	}
+
+	return string(i), string(d), true
+}
+
+// place sets the value at the path within the document, creating the objects
+// along the path as needed
+func place(doc any, path ast.Ref, value any) (any, bool) {
+	if len(path) == 0 {
+		return value, true
+	}
+
+	key, ok := path[0].Value.(ast.String)
+	if !ok {
+		return nil, false
+	}
+
+	obj, ok := doc.(map[string]any)
+	if doc != nil && !ok {
+		return nil, false
+	}
+	if obj == nil {
+		obj = map[string]any{}
+	}
+
+	if obj[string(key)], ok = place(obj[string(key)], path[1:], value); !ok {
+		return nil, false
+	}
+
+	return obj, true
+}
+
+// qualify returns the full path of the reference, resolving the import alias
+// or the package the reference is relative to
+func qualify(r *ast.Rule, ref ast.Ref) ast.Ref {
+	head, ok := ref[0].Value.(ast.Var)
+	if !ok {
+		return ref
+	}
+
+	switch {
+	case head.Equal(ast.DefaultRootDocument.Value), head.Equal(ast.InputRootDocument.Value):
+		return ref
+	case importAlias(r.Module, head) != nil:
+		return importAlias(r.Module, head).Concat(ref[1:])
+	default:
+		return r.Module.Package.Path.Append(ast.StringTerm(string(head))).Concat(ref[1:])
+	}
+}
+
+// localTerms returns the variables in the rule's body that are assigned a
+// value
+func localTerms(r *ast.Rule) map[ast.Var]*ast.Term {
+	locals := map[ast.Var]*ast.Term{}
+	for _, e := range r.Body {
+		if (!e.IsAssignment() && !e.IsEquality()) || len(e.With) > 0 {
+			continue
+		}
+
+		operands := e.Operands()
+		if len(operands) != 2 {
+			continue
+		}
+
+		if v, ok := operands[0].Value.(ast.Var); ok {
+			locals[v] = operands[1]
+		}
+	}
+
+	return locals
+}
+
+// expand replaces the variables assigned in the rule's body and the
+// references to constant rules within the term with their values
+func expand(rules map[string][]*ast.Rule, r *ast.Rule, locals map[ast.Var]*ast.Term, t *ast.Term, depth int) *ast.Term {
+	if depth == 0 {
+		return t
+	}
+
+	each := func(terms []*ast.Term) []*ast.Term {
+		expanded := make([]*ast.Term, 0, len(terms))
+		for _, e := range terms {
+			expanded = append(expanded, expand(rules, r, locals, e, depth))
+		}
+
+		return expanded
+	}
+
+	switch v := t.Value.(type) {
+	case ast.Var:
+		if l, ok := locals[v]; ok {
+			return expand(rules, r, locals, l, depth-1)
+		}
+	case *ast.Array:
+		terms := make([]*ast.Term, 0, v.Len())
+		v.Foreach(func(e *ast.Term) {
+			terms = append(terms, e)
+		})
+
+		return ast.ArrayTerm(each(terms)...)
+	case ast.Set:
+		return ast.SetTerm(each(v.Slice())...)
+	case ast.Object:
+		pairs := make([][2]*ast.Term, 0, v.Len())
+		v.Foreach(func(k, e *ast.Term) {
+			pairs = append(pairs, [2]*ast.Term{expand(rules, r, locals, k, depth), expand(rules, r, locals, e, depth)})
+		})
+
+		return ast.ObjectTerm(pairs...)
+	case ast.Call:
+		args := each(v[1:])
+		if inlined, ok := inline(rules, r, v[0], args, depth); ok {
+			return inlined
+		}
+
+		return ast.CallTerm(append([]*ast.Term{v[0]}, args...)...)
+	}
+
+	// references to constant rules, in this or in other modules
+	defs := rules[resolve(rules, r, t)]
+	if len(defs) != 1 || defs[0].Head.Value == nil || len(defs[0].Head.Args) > 0 || !defs[0].Default && !unconditional(defs[0].Body) {
+		return t
+	}
+
+	// only references to the whole document of the rule are expanded
+	ref, ok := t.Value.(ast.Ref)
+	if !ok {
+		ref = ast.Ref{t}
+	}
+	if !qualify(r, ref).Equal(defs[0].Ref()) {
+		return t
+	}
+
+	return expand(rules, defs[0], localTerms(defs[0]), defs[0].Head.Value, depth-1)
+}
+
+// inline returns the value of the call to a function defined unconditionally,
+// e.g. a helper of the test building the input, when the values of all of the
+// arguments can be determined
+func inline(rules map[string][]*ast.Rule, r *ast.Rule, operator *ast.Term, args []*ast.Term, depth int) (*ast.Term, bool) {
+	defs := rules[resolve(rules, r, operator)]
+	if len(defs) != 1 || defs[0].Head.Value == nil || len(defs[0].Head.Args) != len(args) || !unconditional(defs[0].Body) {
+		return nil, false
+	}
+
+	locals := localTerms(defs[0])
+	for i, p := range defs[0].Head.Args {
+		v, ok := p.Value.(ast.Var)
+		if !ok {
+			return nil, false
+		}
+
+		arg, ok := evaluate(args[i])
+		if !ok {
+			return nil, false
+		}
+		locals[v] = ast.NewTerm(arg)
+	}
+
+	return expand(rules, defs[0], locals, defs[0].Head.Value, depth-1), true
+}
+
+// unconditional reports whether the body of the rule is empty, or only
+// assigns local variables, i.e. the rule is always defined
+func unconditional(body ast.Body) bool {
+	if body.Equal(ast.NewBody(ast.NewExpr(ast.BooleanTerm(true)))) {
+		return true
+	}
+
+	for _, e := range body {
+		if !e.IsAssignment() || len(e.With) > 0 {
+			return false
+		}
+
+		if _, ok := e.Operands()[0].Value.(ast.Var); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// impure are the deterministic built-in functions that have side effects, they
+// are not evaluated along with the non-deterministic ones, e.g. http.send
+var impure = map[string]bool{
+	ast.Print.Name: true,
+	ast.Trace.Name: true,
+}
+
+// evaluate returns the value of the term, evaluating the calls to pure
+// built-in functions it contains. False is returned if the term references
+// variables, documents or functions, or calls a built-in function that is not
+// pure, i.e. if its value can not be determined without the rest of the
+// policy or it could differ between runs.
+func evaluate(t *ast.Term) (ast.Value, bool) {
+	if ast.IsConstant(t.Value) {
+		return t.Value, true
+	}
+
+	if !pure(t) {
+		return nil, false
+	}
+
+	result := ast.VarTerm("result")
+	compiler := ast.NewCompiler()
+	query, err := compiler.QueryCompiler().Compile(ast.NewBody(ast.Equality.Expr(result, t)))
+	if err != nil {
+		return nil, false
+	}
+
+	store := inmem.New()
+	ctx := context.Background()
+	txn, err := store.NewTransaction(ctx)
+	if err != nil {
+		return nil, false
+	}
+	defer store.Abort(ctx, txn)
+
+	rs, err := topdown.NewQuery(query).WithCompiler(compiler).WithStore(store).WithTransaction(txn).Run(ctx)
+	if err != nil || len(rs) != 1 {
+		return nil, false
+	}
+
+	v, ok := rs[0][result.Value.(ast.Var)]
+	if !ok {
+		return nil, false
+	}
+
+	return v.Value, true
+}
+
+// pure reports whether all functions the term calls are built-in functions
+// that are deterministic and have no side effects
+func pure(t *ast.Term) bool {
+	ok := true
+	ast.WalkTerms(t, func(t *ast.Term) bool {
+		call, isCall := t.Value.(ast.Call)
+		if !isCall {
+			return !ok
+		}
+
+		b, found := ast.BuiltinMap[call[0].String()]
+		if !found || b.Nondeterministic || impure[b.Name] {
+			ok = false
+		}
+
+		return !ok
+	})
+
+	return ok
+}
@@ -0,0 +1,228 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+const examplesPolicy = `package policy.release.pkg
+
+import rego.v1
+
+# METADATA
+# title: A rule
+# description: A rule failing for an input
+# custom:
+#   short_name: a_rule
+#   failure_msg: Value %v
+deny contains result if {
+	input.x > 1
+	result := {"code": "pkg.a_rule", "msg": sprintf("Value %v", [input.x])}
+}
+`
+
+const examplesHelpers = `package lib
+
+import rego.v1
+
+assert_equal_results(a, b) if {
+	a == b
+}
+`
+
+func TestExtractExamples(t *testing.T) {
+	cases := []struct {
+		name string
+		test string
+		// input is the expected input, no example is expected if empty
+		input string
+		data  string
+	}{
+		{
+			name: "literals",
+			test: `test_a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input as {"x": 2}
+}`,
+			input: `{"x": 2}`,
+		},
+		{
+			name: "expected results in either argument",
+			test: `test_a if {
+	lib.assert_equal_results(pkg.deny, {{"code": "pkg.a_rule", "msg": "Value 2"}}) with input as {"x": 2}
+}`,
+			input: `{"x": 2}`,
+		},
+		{
+			name: "local variables",
+			test: `test_a if {
+	expected := {{"code": "pkg.a_rule", "msg": "Value 2"}}
+	value := {"x": 2}
+	lib.assert_equal_results(expected, pkg.deny) with input as value
+}`,
+			input: `{"x": 2}`,
+		},
+		{
+			name: "constant rule",
+			test: `_input := {"x": 2}
+
+test_a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input as _input
+}`,
+			input: `{"x": 2}`,
+		},
+		{
+			name: "helper function",
+			test: `_input(x) := {"x": x}
+
+test_a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input as _input(2)
+}`,
+			input: `{"x": 2}`,
+		},
+		{
+			name: "pure built-in function",
+			test: `test_a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input as json.unmarshal("{\"x\": 2}")
+}`,
+			input: `{"x": 2}`,
+		},
+		{
+			name: "input and data placed at their path",
+			test: `test_a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input.x as 2 with data.rule_data.y as 1
+}`,
+			input: `{"x": 2}`,
+			data:  `{"rule_data": {"y": 1}}`,
+		},
+		{
+			name: "non-deterministic built-in function",
+			test: `test_a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input as {"x": 2, "t": time.now_ns()}
+}`,
+		},
+		{
+			name: "built-in function with side effects",
+			test: `test_a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input as {"x": 2, "r": http.send({"method": "get", "url": "http://localhost"})}
+}`,
+		},
+		{
+			name: "replaced function",
+			test: `test_a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input as {"x": 2} with time.now_ns as 1
+}`,
+		},
+		{
+			name: "input from another document",
+			test: `test_a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input as data.inputs.a
+}`,
+		},
+		{
+			name: "not an assertion",
+			test: `test_a if {
+	pkg.deny == {{"code": "pkg.a_rule", "msg": "Value 2"}} with input as {"x": 2}
+}`,
+		},
+		{
+			name: "not a test",
+			test: `a if {
+	lib.assert_equal_results({{"code": "pkg.a_rule", "msg": "Value 2"}}, pkg.deny) with input as {"x": 2}
+}`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "policy/lib/assert.rego", examplesHelpers)
+			writeFile(t, dir, "policy/release/pkg/pkg.rego", examplesPolicy)
+			writeFile(t, dir, "policy/release/pkg/pkg_test.rego", `package policy.release.pkg_test
+
+import rego.v1
+
+import data.lib
+import data.policy.release.pkg
+
+`+c.test+"\n")
+
+			roots := []root{{Path: dir}}
+			annotations, modules, err := inspect(roots, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ex, err := extractExamples(modules, annotations, roots, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got *example
+			for _, set := range annotations {
+				for _, ref := range set {
+					if ref.Annotations.Title == "A rule" {
+						got = ex.Of(ref.Annotations)
+					}
+				}
+			}
+
+			if c.input == "" {
+				if got != nil {
+					t.Errorf("expected no example, got %+v", got)
+				}
+				return
+			}
+
+			if got == nil {
+				t.Fatal("expected an example, got none")
+			}
+
+			if got.Test != "test_a" || got.File != "policy/release/pkg/pkg_test.rego" || got.Message != "Value 2" {
+				t.Errorf("unexpected example %+v", got)
+			}
+
+			assertJSON(t, "input", got.Input, c.input)
+			assertJSON(t, "data", got.Data, c.data)
+		})
+	}
+}
+
+func assertJSON(t *testing.T, name, got, want string) {
+	t.Helper()
+
+	if got == "" || want == "" {
+		if got != want {
+			t.Errorf("got %s %q, want %q", name, got, want)
+		}
+		return
+	}
+
+	var g, w bytes.Buffer
+	if err := json.Compact(&g, []byte(got)); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Compact(&w, []byte(want)); err != nil {
+		t.Fatal(err)
+	}
+
+	if g.String() != w.String() {
+		t.Errorf("got %s %s, want %s", name, g.String(), w.String())
+	}
+}
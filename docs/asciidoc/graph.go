@@ -0,0 +1,277 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// graphFormats maps the supported dependency graph formats to the name of the
+// file the graph is written to
+var graphFormats = map[string]string{
+	"dot":     "rule_dependencies.dot",
+	"mermaid": "rule_dependencies.mmd",
+}
+
+// GraphFormats returns the names of the supported dependency graph formats
+func GraphFormats() []string {
+	formats := make([]string, 0, len(graphFormats))
+	for f := range graphFormats {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+
+	return formats
+}
+
+// ruleRef identifies a rule and the page it is documented on
+type ruleRef struct {
+	Code  string
+	Title string
+	// Origin is the policy kind of the rule, empty if the rule is not
+	// documented, e.g. a dependency on a rule that doesn't exist
+	Origin  string
+	Package string
+	Anchor  string
+}
+
+// graph holds the dependencies between rules declared via the
+// custom.depends_on annotation, and the replacements of deprecated rules
+// declared via the custom.replaced_by annotation
+type graph struct {
+	// rules holds all rules by their code, as package names are not unique
+	// across policy kinds there can be more than one rule with the same code
+	rules map[string][]ruleRef
+	// dependsOn holds the dependencies of each rule, keyed by the origin and
+	// the code of the dependent rule
+	dependsOn map[ruleKey][]ruleRef
+	// dependents holds the rules depending on each rule, keyed by the origin
+	// and the code of the dependency
+	dependents map[ruleKey][]ruleRef
+	// replacedBy holds the replacement of each deprecated rule, keyed by the
+	// origin and the code of the deprecated rule
+	replacedBy map[ruleKey]ruleRef
+	// origins gives the policy kind of the annotated rules
+	origins origins
+}
+
+type ruleKey struct {
+	origin string
+	code   string
+}
+
+// newGraph builds the dependency graph of all rules documented by the given
+// policy kinds
+func newGraph(docs []doc, o origins) *graph {
+	g := graph{
+		rules:      map[string][]ruleRef{},
+		dependsOn:  map[ruleKey][]ruleRef{},
+		dependents: map[ruleKey][]ruleRef{},
+		replacedBy: map[ruleKey]ruleRef{},
+		origins:    o,
+	}
+
+	type edge struct {
+		from ruleRef
+		to   []string
+	}
+	edges := make([]edge, 0, 50)
+	replacements := map[ruleKey]string{}
+
+	for _, d := range docs {
+		for _, p := range *d.Packages {
+			for _, a := range *p.Rules {
+				r := newRuleRef(&p, a)
+				g.rules[r.Code] = append(g.rules[r.Code], r)
+				if deps := customStrings(a, "depends_on"); len(deps) > 0 {
+					edges = append(edges, edge{r, deps})
+				}
+				if code := customString(a, "replaced_by"); code != "" {
+					replacements[ruleKey{r.Origin, r.Code}] = code
+				}
+			}
+		}
+	}
+
+	for _, e := range edges {
+		from := ruleKey{e.from.Origin, e.from.Code}
+		for _, code := range e.to {
+			to := g.resolve(e.from.Origin, code)
+			g.dependsOn[from] = append(g.dependsOn[from], to)
+			if to.Origin != "" {
+				k := ruleKey{to.Origin, to.Code}
+				g.dependents[k] = append(g.dependents[k], e.from)
+			}
+		}
+	}
+
+	for k, code := range replacements {
+		g.replacedBy[k] = g.resolve(k.origin, code)
+	}
+
+	for _, refs := range g.dependents {
+		sort.Slice(refs, func(i, j int) bool {
+			return refs[i].Code < refs[j].Code
+		})
+	}
+
+	return &g
+}
+
+func newRuleRef(p *pkg, a *ast.Annotations) ruleRef {
+	name := packageName(p)
+	anchor, _ := anchor(a)
+
+	return ruleRef{
+		Code:    fmt.Sprintf("%s.%s", name, a.Custom["short_name"]),
+		Title:   a.Title,
+		Origin:  p.Origin,
+		Package: name,
+		Anchor:  anchor,
+	}
+}
+
+// resolve finds the rule with the given code, preferring the rule from the
+// same policy kind. If no rule with the code exists a ruleRef with only the
+// code set is returned.
+func (g *graph) resolve(origin, code string) ruleRef {
+	candidates := g.rules[code]
+	for _, c := range candidates {
+		if c.Origin == origin {
+			return c
+		}
+	}
+
+	if len(candidates) > 0 {
+		return candidates[0]
+	}
+
+	return ruleRef{Code: code}
+}
+
+func (g *graph) key(a *ast.Annotations) ruleKey {
+	path := a.GetTargetPath()
+	name := ""
+	if len(path) > 1 {
+		name = strings.Trim(path[len(path)-2].String(), `"`)
+	}
+
+	return ruleKey{g.origins.kind(a.Location.File), fmt.Sprintf("%s.%s", name, a.Custom["short_name"])}
+}
+
+// DependsOn returns the rules the rule with the given annotations depends on
+func (g *graph) DependsOn(a *ast.Annotations) []ruleRef {
+	if g == nil {
+		return nil
+	}
+
+	return g.dependsOn[g.key(a)]
+}
+
+// Dependents returns the rules that depend on the rule with the given
+// annotations
+func (g *graph) Dependents(a *ast.Annotations) []ruleRef {
+	if g == nil {
+		return nil
+	}
+
+	return g.dependents[g.key(a)]
+}
+
+// ReplacedBy returns the rule replacing the deprecated rule with the given
+// annotations, nil if the rule has no replacement
+func (g *graph) ReplacedBy(a *ast.Annotations) *ruleRef {
+	if g == nil {
+		return nil
+	}
+
+	r, ok := g.replacedBy[g.key(a)]
+	if !ok {
+		return nil
+	}
+
+	return &r
+}
+
+// sortedEdges returns all edges, from the dependent rule to its dependency,
+// in a stable order
+func (g *graph) sortedEdges() [][2]ruleRef {
+	edges := make([][2]ruleRef, 0, len(g.dependsOn))
+	for from, deps := range g.dependsOn {
+		f := g.resolve(from.origin, from.code)
+		for _, to := range deps {
+			edges = append(edges, [2]ruleRef{f, to})
+		}
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		a := edges[i][0].Origin + edges[i][0].Code + edges[i][1].Code
+		b := edges[j][0].Origin + edges[j][0].Code + edges[j][1].Code
+		return a < b
+	})
+
+	return edges
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// nodeID returns an identifier for the rule usable in DOT and Mermaid
+func nodeID(r ruleRef) string {
+	return nonIdentifier.ReplaceAllString(r.Origin+"__"+r.Code, "_")
+}
+
+// nodeLabel returns the label of the rule in the graph
+func nodeLabel(r ruleRef) string {
+	if r.Origin == "" {
+		return r.Code + " (missing)"
+	}
+
+	return r.Origin + ": " + r.Code
+}
+
+// writeGraph renders the dependency graph in the given format, the edges
+// point from the dependent rule to the rule it depends on
+func writeGraph(g *graph, format string) func(io.Writer) error {
+	return func(w io.Writer) error {
+		var b strings.Builder
+		edges := g.sortedEdges()
+		switch format {
+		case "dot":
+			b.WriteString("digraph rule_dependencies {\n  rankdir=LR;\n  node [shape=box];\n")
+			for _, e := range edges {
+				fmt.Fprintf(&b, "  %q -> %q;\n", nodeLabel(e[0]), nodeLabel(e[1]))
+			}
+			b.WriteString("}\n")
+		case "mermaid":
+			b.WriteString("flowchart LR\n")
+			for _, e := range edges {
+				fmt.Fprintf(&b, "  %s[%q] --> %s[%q]\n", nodeID(e[0]), nodeLabel(e[0]), nodeID(e[1]), nodeLabel(e[1]))
+			}
+		default:
+			return fmt.Errorf("unsupported graph format %q, expecting one of: %s", format, strings.Join(GraphFormats(), ", "))
+		}
+
+		_, err := io.WriteString(w, b.String())
+		return err
+	}
+}
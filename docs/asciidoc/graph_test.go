@@ -0,0 +1,179 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// graphModel loads the rules with dependencies, within the same and across
+// policy kinds, the package a is present in both the release and the task
+// policy
+func graphModel(t *testing.T) *model {
+	t.Helper()
+
+	// only the packages with a METADATA block are documented
+	documented := func(pkg string, rules ...string) string {
+		return "# METADATA\n# title: " + pkg + "\n" + conventionsModule(pkg, rules...)
+	}
+
+	dir := t.TempDir()
+	writeFile(t, dir, "policy/release/a/a.rego", documented("a",
+		"short_name: one",
+		"short_name: two\ndepends_on:\n- a.one\n- x.missing",
+		"short_name: old\ndeprecated:\n  since: 2025-06-01T00:00:00Z\n  reason: Replaced\nreplaced_by: a.two"))
+	writeFile(t, dir, "policy/release/c/c.rego", documented("c", "short_name: only"))
+	writeFile(t, dir, "policy/task/a/a.rego", documented("a", "short_name: one"))
+	writeFile(t, dir, "policy/task/b/b.rego", documented("b", "short_name: uses\ndepends_on:\n- a.one\n- c.only"))
+
+	m, err := load(nil, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+// refs formats the rules as origin:code
+func refs(rs ...ruleRef) []string {
+	s := make([]string, 0, len(rs))
+	for _, r := range rs {
+		s = append(s, r.Origin+":"+r.Code)
+	}
+
+	return s
+}
+
+func TestGraph(t *testing.T) {
+	m := graphModel(t)
+
+	rules := map[string]*ast.Annotations{}
+	for _, d := range m.docs {
+		for _, p := range *d.Packages {
+			for _, a := range *p.Rules {
+				rules[refs(newRuleRef(&p, a))[0]] = a
+			}
+		}
+	}
+
+	cases := []struct {
+		rule       string
+		dependsOn  []string
+		dependents []string
+		replacedBy string
+	}{
+		{rule: "release:a.one", dependents: []string{"release:a.two"}},
+		{rule: "release:a.two", dependsOn: []string{"release:a.one", ":x.missing"}},
+		{rule: "release:a.old", replacedBy: "release:a.two"},
+		{rule: "release:c.only", dependents: []string{"task:b.uses"}},
+		{rule: "task:a.one", dependents: []string{"task:b.uses"}},
+		{rule: "task:b.uses", dependsOn: []string{"task:a.one", "release:c.only"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.rule, func(t *testing.T) {
+			a, ok := rules[c.rule]
+			if !ok {
+				t.Fatalf("rule %s not found in %v", c.rule, rules)
+			}
+
+			if got := refs(m.graph.DependsOn(a)...); !slices.Equal(got, c.dependsOn) {
+				t.Errorf("got dependencies %v, want %v", got, c.dependsOn)
+			}
+
+			if got := refs(m.graph.Dependents(a)...); !slices.Equal(got, c.dependents) {
+				t.Errorf("got dependents %v, want %v", got, c.dependents)
+			}
+
+			got := ""
+			if r := m.graph.ReplacedBy(a); r != nil {
+				got = refs(*r)[0]
+			}
+			if got != c.replacedBy {
+				t.Errorf("got replacement %q, want %q", got, c.replacedBy)
+			}
+		})
+	}
+
+	t.Run("nil graph", func(t *testing.T) {
+		var g *graph
+		a := rules["release:a.two"]
+		if g.DependsOn(a) != nil || g.Dependents(a) != nil || g.ReplacedBy(a) != nil {
+			t.Error("expected no relations from a nil graph")
+		}
+	})
+}
+
+func TestWriteGraph(t *testing.T) {
+	m := graphModel(t)
+
+	cases := []struct {
+		format string
+		want   string
+		err    string
+	}{
+		{
+			format: "dot",
+			want: `digraph rule_dependencies {
+  rankdir=LR;
+  node [shape=box];
+  "release: a.two" -> "release: a.one";
+  "release: a.two" -> "x.missing (missing)";
+  "task: b.uses" -> "task: a.one";
+  "task: b.uses" -> "release: c.only";
+}
+`,
+		},
+		{
+			format: "mermaid",
+			want: `flowchart LR
+  release__a_two["release: a.two"] --> release__a_one["release: a.one"]
+  release__a_two["release: a.two"] --> __x_missing["x.missing (missing)"]
+  task__b_uses["task: b.uses"] --> task__a_one["task: a.one"]
+  task__b_uses["task: b.uses"] --> release__c_only["release: c.only"]
+`,
+		},
+		{
+			format: "svg",
+			err:    `unsupported graph format "svg", expecting one of: dot, mermaid`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.format, func(t *testing.T) {
+			var got strings.Builder
+			err := writeGraph(m.graph, c.format)(&got)
+			if c.err != "" {
+				if err == nil || err.Error() != c.err {
+					t.Fatalf("expected error %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got.String() != c.want {
+				t.Errorf("got graph:\n%s\nwant:\n%s", got.String(), c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Kind describes a kind of policy, i.e. a top level directory under policy/,
+// for which the navigation, policy and package pages are generated
+type Kind struct {
+	Name        string `json:"name"`
+	Qualifier   string `json:"qualifier"`
+	Description string `json:"description"`
+}
+
+// Manifest is the configuration file format listing the policy kinds to
+// document, for example:
+//
+//	kinds:
+//	- name: Release
+//	  qualifier: release
+//	  description: These rules are applied to ...
+type Manifest struct {
+	Kinds []Kind `json:"kinds"`
+}
+
+// defaultKinds holds the names and descriptions of the well known policy
+// kinds, these are also used when discovering kinds to keep the order and
+// the wording of the pages stable
+var defaultKinds = []Kind{
+	{
+		Name:        "Release",
+		Qualifier:   "release",
+		Description: "These rules are applied to pipeline run attestations associated with container images built by Konflux.",
+	},
+	{
+		Name:        "Pipeline",
+		Qualifier:   "pipeline",
+		Description: "These rules are applied to Tekton pipeline definitions.",
+	},
+	{
+		Name:        "Task",
+		Qualifier:   "task",
+		Description: "These rules are applied to Tekton task definitions.",
+	},
+	{
+		Name:        "Build Task",
+		Qualifier:   "build_task",
+		Description: "These rules are applied to Tekton build task definitions.",
+	},
+	{
+		Name:        "StepAction",
+		Qualifier:   "stepaction",
+		Description: "These rules are applied to Tekton StepAction definitions.",
+	},
+}
+
+// nonKindDirectories are the directories under policy/ that do not hold
+// policy rules
+var nonKindDirectories = map[string]bool{
+	"lib": true,
+}
+
+// LoadKinds reads the policy kinds from a YAML or JSON manifest file
+func LoadKinds(path string) ([]Kind, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading kinds manifest %q: %w", path, err)
+	}
+
+	var m Manifest
+	if err := yaml.UnmarshalStrict(data, &m); err != nil {
+		return nil, fmt.Errorf("parsing kinds manifest %q: %w", path, err)
+	}
+
+	seen := map[string]bool{}
+	for i, k := range m.Kinds {
+		if k.Qualifier == "" {
+			return nil, fmt.Errorf("kinds manifest %q: kind at index %d has no qualifier", path, i)
+		}
+		if seen[k.Qualifier] {
+			return nil, fmt.Errorf("kinds manifest %q: kind with qualifier %q is listed more than once", path, k.Qualifier)
+		}
+		seen[k.Qualifier] = true
+
+		if k.Name == "" {
+			m.Kinds[i].Name = nameFromQualifier(k.Qualifier)
+		}
+	}
+
+	return m.Kinds, nil
+}
+
+// DiscoverKinds finds the policy kinds from the top level directories under
+// policy/ in each of the given Rego directories, and from the kinds declared
+// by the Rego directories, e.g. <dir>,kind=release. The well known kinds are
+// returned first with their predefined names and descriptions, any other
+// kinds follow in alphabetical order.
+func DiscoverKinds(rego ...string) ([]Kind, error) {
+	roots, err := parseRoots(rego)
+	if err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	for _, r := range roots {
+		if r.Kind != "" {
+			found[r.Kind] = true
+		}
+
+		entries, err := os.ReadDir(filepath.Join(r.Path, "policy"))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("discovering policy kinds in %q: %w", r.Path, err)
+		}
+
+		for _, e := range entries {
+			if e.IsDir() && !nonKindDirectories[e.Name()] && !strings.HasPrefix(e.Name(), ".") {
+				found[e.Name()] = true
+			}
+		}
+	}
+
+	kinds := make([]Kind, 0, len(found))
+	for _, k := range defaultKinds {
+		if found[k.Qualifier] {
+			kinds = append(kinds, k)
+			delete(found, k.Qualifier)
+		}
+	}
+
+	other := make([]string, 0, len(found))
+	for q := range found {
+		other = append(other, q)
+	}
+	sort.Strings(other)
+
+	for _, q := range other {
+		kinds = append(kinds, Kind{
+			Name:        nameFromQualifier(q),
+			Qualifier:   q,
+			Description: fmt.Sprintf("These rules are defined in the policy/%s directory.", q),
+		})
+	}
+
+	return kinds, nil
+}
+
+// nameFromQualifier converts a qualifier to a name suitable for a title, e.g.
+// custom_org becomes Custom Org
+func nameFromQualifier(q string) string {
+	words := strings.FieldsFunc(q, func(r rune) bool {
+		return r == '_' || r == '-'
+	})
+
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,173 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadKinds(t *testing.T) {
+	cases := []struct {
+		name     string
+		manifest string
+		want     []Kind
+		err      string
+	}{
+		{
+			name:     "YAML",
+			manifest: "kinds:\n- name: Release\n  qualifier: release\n  description: Release rules.\n- qualifier: custom_org\n",
+			want: []Kind{
+				{Name: "Release", Qualifier: "release", Description: "Release rules."},
+				{Name: "Custom Org", Qualifier: "custom_org"},
+			},
+		},
+		{
+			name:     "JSON",
+			manifest: `{"kinds": [{"name": "Task", "qualifier": "task"}]}`,
+			want:     []Kind{{Name: "Task", Qualifier: "task"}},
+		},
+		{
+			name:     "unknown field",
+			manifest: "kinds:\n- qualifier: release\n  title: Release\n",
+			err:      `parsing kinds manifest`,
+		},
+		{
+			name:     "no qualifier",
+			manifest: "kinds:\n- qualifier: release\n- name: Task\n",
+			err:      "kind at index 1 has no qualifier",
+		},
+		{
+			name:     "duplicate qualifier",
+			manifest: "kinds:\n- qualifier: release\n- qualifier: release\n",
+			err:      `kind with qualifier "release" is listed more than once`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "kinds.yaml", c.manifest)
+
+			got, err := LoadKinds(filepath.Join(dir, "kinds.yaml"))
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected error containing %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got kinds %#v, want %#v", got, c.want)
+			}
+		})
+	}
+
+	t.Run("missing manifest", func(t *testing.T) {
+		if _, err := LoadKinds(filepath.Join(t.TempDir(), "kinds.yaml")); err == nil || !strings.Contains(err.Error(), "reading kinds manifest") {
+			t.Errorf("expected a read error, got %v", err)
+		}
+	})
+}
+
+func TestDiscoverKinds(t *testing.T) {
+	cases := []struct {
+		name  string
+		files []string
+		// roots are the specifications of the Rego directories, $DIR is
+		// replaced by the temporary directory
+		roots []string
+		want  []string
+	}{
+		{
+			name:  "well known kinds first",
+			files: []string{"policy/zeta/a.rego", "policy/task/a.rego", "policy/alpha/a.rego", "policy/release/a.rego"},
+			roots: []string{"$DIR"},
+			want:  []string{"release", "task", "alpha", "zeta"},
+		},
+		{
+			name:  "library and hidden directories",
+			files: []string{"policy/lib/a.rego", "policy/.hidden/a.rego", "policy/task/a.rego"},
+			roots: []string{"$DIR"},
+			want:  []string{"task"},
+		},
+		{
+			name:  "without a policy directory",
+			files: []string{"a.rego"},
+			roots: []string{"$DIR"},
+			want:  []string{},
+		},
+		{
+			name:  "declared kinds",
+			files: []string{"one/policy/task/a.rego", "two/a.rego", "three/a.rego"},
+			roots: []string{"$DIR/one", "$DIR/two,kind=release", "local=$DIR/three,kind=custom_org"},
+			want:  []string{"release", "task", "custom_org"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range c.files {
+				writeFile(t, dir, f, "package a\n")
+			}
+
+			rego := make([]string, 0, len(c.roots))
+			for _, r := range c.roots {
+				rego = append(rego, strings.ReplaceAll(r, "$DIR", dir))
+			}
+
+			kinds, err := DiscoverKinds(rego...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, 0, len(kinds))
+			for _, k := range kinds {
+				got = append(got, k.Qualifier)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got kinds %v, want %v", got, c.want)
+			}
+		})
+	}
+
+	t.Run("names and descriptions", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "policy/release/a.rego", "package a\n")
+		writeFile(t, dir, "policy/custom_org/a.rego", "package a\n")
+
+		got, err := DiscoverKinds(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []Kind{
+			defaultKinds[0],
+			{Name: "Custom Org", Qualifier: "custom_org", Description: "These rules are defined in the policy/custom_org directory."},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got kinds %#v, want %#v", got, want)
+		}
+	})
+}
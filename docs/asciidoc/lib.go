@@ -0,0 +1,312 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// libraryPage is the name, without the extension, of the page listing the
+// library packages, the page of each package is within the directory of the
+// same name
+const libraryPage = "library"
+
+// libRoot is the path of the library packages, the helpers used by the policy
+// rules
+var libRoot = ast.MustParseRef("data.lib")
+
+// libPackage documents a library package, which can span many files
+type libPackage struct {
+	// Name is the name of the package, e.g. lib.tekton
+	Name    string
+	Members []libMember
+}
+
+// Page returns the name of the package's page, without the extension
+func (p libPackage) Page() string {
+	return strings.ReplaceAll(p.Name, ".", "_")
+}
+
+// Functions returns the members of the package that are functions
+func (p libPackage) Functions() []libMember {
+	return p.members(true)
+}
+
+// Rules returns the members of the package that are rules, e.g. constants
+func (p libPackage) Rules() []libMember {
+	return p.members(false)
+}
+
+func (p libPackage) members(function bool) []libMember {
+	members := make([]libMember, 0, len(p.Members))
+	for _, m := range p.Members {
+		if m.Function == function {
+			members = append(members, m)
+		}
+	}
+
+	return members
+}
+
+// libMember documents an exported function or rule of a library package, it
+// can be defined many times, e.g. by an incremental rule or by a function
+// with many bodies
+type libMember struct {
+	Name  string
+	Title string
+	// Description is from the METADATA block of the definition, or from the
+	// comments right before it
+	Description string
+	// Signatures holds the distinct heads of the definitions, e.g.
+	// task_ref(task) := j
+	Signatures []string
+	// Returns describes the returned value, from the custom.returns
+	// annotation or inferred from the definitions
+	Returns  string
+	Function bool
+	Location *ast.Location
+	defs     []*ast.Rule
+}
+
+// libWarning reports an exported library function without a description
+type libWarning struct {
+	Location *ast.Location
+	Name     string
+}
+
+func (w libWarning) String() string {
+	return fmt.Sprintf("%s:%d: warning: library function %s has no description", w.Location.File, w.Location.Row, w.Name)
+}
+
+// libraryPackages documents the exported functions and rules, i.e. those not
+// starting with an underscore, of the library packages found in the modules,
+// along with warnings for the functions without a description
+func libraryPackages(modules []*ast.Module, annotations []ast.FlatAnnotationsRefSet) ([]libPackage, []libWarning) {
+	documented := map[*ast.Rule]*ast.Annotations{}
+	for _, set := range annotations {
+		for _, ref := range set {
+			if r := ref.GetRule(); r != nil && ref.Annotations.Scope == "rule" {
+				documented[r] = ref.Annotations
+			}
+		}
+	}
+
+	members := map[string]map[string]*libMember{}
+	for _, m := range modules {
+		if !m.Package.Path.HasPrefix(libRoot) {
+			continue
+		}
+
+		pkg := strings.TrimPrefix(m.Package.Path.String(), "data.")
+		if members[pkg] == nil {
+			members[pkg] = map[string]*libMember{}
+		}
+
+		comments := map[int]string{}
+		for _, c := range m.Comments {
+			comments[c.Location.Row] = string(c.Text)
+		}
+
+		for _, r := range m.Rules {
+			// the definitions of a partial object rule, e.g. o[k] := v, are
+			// documented under the name of the object
+			name := r.Head.Ref().GroundPrefix().String()
+			if strings.HasPrefix(name, "_") {
+				continue
+			}
+
+			member, ok := members[pkg][name]
+			if !ok {
+				member = &libMember{Name: name, Function: len(r.Head.Args) > 0, Location: r.Location}
+				members[pkg][name] = member
+			}
+
+			member.defs = append(member.defs, r)
+
+			signature := signature(r)
+			if !slices.Contains(member.Signatures, signature) {
+				member.Signatures = append(member.Signatures, signature)
+			}
+
+			if member.Description != "" {
+				continue
+			}
+
+			if a, ok := documented[r]; ok {
+				member.Title = a.Title
+				member.Description = a.Description
+				member.Returns = customString(a, "returns")
+			} else {
+				member.Description = precedingComment(comments, r.Location.Row)
+			}
+		}
+	}
+
+	packages := make([]libPackage, 0, len(members))
+	var warnings []libWarning
+	for name, ms := range members {
+		p := libPackage{Name: name, Members: make([]libMember, 0, len(ms))}
+		for _, m := range ms {
+			if m.Returns == "" {
+				m.Returns = returns(m.defs)
+			}
+			p.Members = append(p.Members, *m)
+
+			if m.Function && m.Description == "" {
+				warnings = append(warnings, libWarning{Location: m.Location, Name: name + "." + m.Name})
+			}
+		}
+
+		sort.Slice(p.Members, func(i, j int) bool {
+			return p.Members[i].Name < p.Members[j].Name
+		})
+
+		packages = append(packages, p)
+	}
+
+	sort.Slice(packages, func(i, j int) bool {
+		return packages[i].Name < packages[j].Name
+	})
+
+	sort.Slice(warnings, func(i, j int) bool {
+		if warnings[i].Location.File != warnings[j].Location.File {
+			return warnings[i].Location.File < warnings[j].Location.File
+		}
+
+		return warnings[i].Location.Row < warnings[j].Location.Row
+	})
+
+	return packages, warnings
+}
+
+// signature returns the head of the rule without the else branches, e.g.
+// task_ref(task) := j, the value of boolean functions is omitted and composite
+// values are abbreviated
+func signature(r *ast.Rule) string {
+	head := r.Head.Ref().String()
+	if len(r.Head.Args) > 0 {
+		head += r.Head.Args.String()
+	}
+
+	if r.Head.Value == nil && r.Head.Key != nil {
+		return head + " contains " + r.Head.Key.String()
+	}
+
+	if r.Head.Value == nil || ast.BooleanTerm(true).Equal(r.Head.Value) {
+		return head
+	}
+
+	value := r.Head.Value.String()
+	switch r.Head.Value.Value.(type) {
+	case *ast.Array, *ast.ArrayComprehension:
+		value = "[...]"
+	case ast.Object, *ast.ObjectComprehension:
+		value = "{...}"
+	case ast.Set, *ast.SetComprehension:
+		value = "{...}"
+	case ast.Call:
+		value = "..."
+	}
+
+	if r.Head.Assign {
+		return head + " := " + value
+	}
+
+	return head + " = " + value
+}
+
+// returns describes the value returned by all the definitions: the type of
+// the value, when the definitions are all literals, or comprehensions, of the
+// same type, e.g. boolean for a function that checks a condition, or any
+// otherwise
+func returns(defs []*ast.Rule) string {
+	types := map[string]bool{}
+	for _, r := range defs {
+		if r.Head.Value == nil {
+			// contains, i.e. a partial set rule
+			types["set"] = true
+			continue
+		}
+
+		if r.Head.Key != nil || !r.Head.Ref().IsGround() {
+			// a partial object rule, e.g. o[k] := v
+			types["object"] = true
+			continue
+		}
+
+		for e := r; e != nil; e = e.Else {
+			types[typeName(e.Head.Value.Value)] = true
+		}
+	}
+
+	if len(types) != 1 {
+		return "any"
+	}
+
+	for t := range types {
+		return t
+	}
+
+	return "any"
+}
+
+// typeName returns the type of the literal value, any if it is not a literal
+func typeName(v ast.Value) string {
+	switch v.(type) {
+	case ast.Boolean:
+		return "boolean"
+	case ast.String:
+		return "string"
+	case ast.Number:
+		return "number"
+	case ast.Null:
+		return "null"
+	case *ast.Array, *ast.ArrayComprehension:
+		return "array"
+	case ast.Object, *ast.ObjectComprehension:
+		return "object"
+	case ast.Set, *ast.SetComprehension:
+		return "set"
+	default:
+		return "any"
+	}
+}
+
+// precedingComment returns the block of comments ending right before the
+// given row, without the linter directives, e.g. regal ignore:rule-length
+func precedingComment(comments map[int]string, row int) string {
+	lines := make([]string, 0, 5)
+	for r := row - 1; ; r-- {
+		c, ok := comments[r]
+		if !ok {
+			break
+		}
+
+		if strings.HasPrefix(strings.TrimSpace(c), "regal ") {
+			continue
+		}
+
+		lines = append([]string{strings.TrimPrefix(c, " ")}, lines...)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
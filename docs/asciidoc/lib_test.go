@@ -0,0 +1,185 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// libRules parses the Rego rules as the body of a library module
+func libRules(t *testing.T, rules string) []*ast.Rule {
+	t.Helper()
+
+	m, err := ast.ParseModule("lib.rego", "package lib\n\nimport rego.v1\n\n"+rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m.Rules
+}
+
+func TestSignature(t *testing.T) {
+	cases := []struct {
+		rule string
+		want string
+	}{
+		{rule: "f(x) := y if { y := x }", want: "f(x) := y"},
+		{rule: "is_x(x) if { x == 1 }", want: "is_x(x)"},
+		{rule: "allowed := true", want: "allowed"},
+		{rule: "name := \"a\"", want: `name := "a"`},
+		{rule: "old = 1", want: "old = 1"},
+		{rule: "list := [1, 2]", want: "list := [...]"},
+		{rule: "keys := {k | some k in input}", want: "keys := {...}"},
+		{rule: "obj := {\"a\": 1}", want: "obj := {...}"},
+		{rule: "upper(s) := upper(s) if { true }", want: "upper(s) := ..."},
+		{rule: "names contains n if { some n in input }", want: "names contains n"},
+		{rule: "f(x) := 1 if { x } else := 2", want: "f(x) := 1"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.rule, func(t *testing.T) {
+			if got := signature(libRules(t, c.rule)[0]); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestReturns(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules string
+		want  string
+	}{
+		{name: "boolean", rules: "f(x) if { x }", want: "boolean"},
+		{name: "string", rules: "f(x) := \"a\" if { x }\nf(x) := \"b\" if { not x }", want: "string"},
+		{name: "else of the same type", rules: "f(x) := 1 if { x } else := 2", want: "number"},
+		{name: "else of another type", rules: "f(x) := 1 if { x } else := \"a\"", want: "any"},
+		{name: "variable", rules: "f(x) := y if { y := x }", want: "any"},
+		{name: "array", rules: "l := [x | some x in input]", want: "array"},
+		{name: "partial set", rules: "s contains x if { some x in input }", want: "set"},
+		{name: "partial object", rules: "o[k] := 1 if { some k in input }", want: "object"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := returns(libRules(t, c.rules)); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
+
+func TestLibraryPackages(t *testing.T) {
+	dir := policyTree(t)
+	writeFile(t, dir, "policy/lib/tekton/task.rego", `package lib.tekton
+
+import rego.v1
+
+# METADATA
+# title: Task name
+# description: Returns the name of the task.
+# custom:
+#   returns: the name
+task_name(task) := task.name
+
+task_name(task) := task.metadata.name if {
+	not task.name
+}
+
+# Checks if the task is trusted.
+# regal ignore:rule-length
+is_trusted(task) if {
+	task.trusted
+}
+
+undocumented(x) := x
+
+tasks contains t if {
+	some t in input.tasks
+}
+
+# The tasks by their name.
+by_name[name] := t if {
+	some t in input.tasks
+	name := t.name
+}
+
+_private(x) := x
+`)
+	writeFile(t, dir, "policy/lib/tekton/refs.rego", `package lib.tekton
+
+import rego.v1
+
+# The kinds of task references.
+ref_kinds := ["bundle", "git"]
+`)
+	writeFile(t, dir, "policy/lib/lib.rego", "package lib\n\nimport rego.v1\n\nf(x) := x\n")
+
+	m, err := load(nil, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := []string{}
+	for _, p := range m.library {
+		for _, f := range p.Functions() {
+			got = append(got, fmt.Sprintf("%s function %s %q %q returns %s", p.Page(), f.Name, f.Title, f.Description, f.Returns))
+			for _, s := range f.Signatures {
+				got = append(got, "  "+s)
+			}
+		}
+		for _, r := range p.Rules() {
+			got = append(got, fmt.Sprintf("%s rule %s %q returns %s", p.Page(), r.Name, r.Description, r.Returns))
+		}
+	}
+
+	want := []string{
+		`lib function f "" "" returns any`,
+		"  f(x) := x",
+		`lib_tekton function is_trusted "" "Checks if the task is trusted." returns boolean`,
+		"  is_trusted(task)",
+		`lib_tekton function task_name "Task name" "Returns the name of the task." returns the name`,
+		"  task_name(task) := task.name",
+		"  task_name(task) := task.metadata.name",
+		`lib_tekton function undocumented "" "" returns any`,
+		"  undocumented(x) := x",
+		`lib_tekton rule by_name "The tasks by their name." returns object`,
+		`lib_tekton rule ref_kinds "The kinds of task references." returns array`,
+		`lib_tekton rule tasks "" returns set`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got library:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	warnings := make([]string, 0, len(m.libraryWarnings))
+	for _, w := range m.libraryWarnings {
+		warnings = append(warnings, w.String())
+	}
+	wantWarnings := []string{
+		"policy/lib/lib.rego:5: warning: library function lib.f has no description",
+		"policy/lib/tekton/task.rego:22: warning: library function lib.tekton.undocumented has no description",
+	}
+	if !reflect.DeepEqual(warnings, wantWarnings) {
+		t.Errorf("got warnings:\n%s\nwant:\n%s", strings.Join(warnings, "\n"), strings.Join(wantWarnings, "\n"))
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// manifestFile lists the files created by the generator within the output
+// directory, it is used to find the files that are no longer generated, e.g.
+// the page of a removed package
+const manifestFile = ".generated"
+
+const manifestHeader = "# Files created by the documentation generator, do not edit.\n"
+
+// pages holds the content of the generated files keyed by their path relative
+// to the output directory
+type pages map[string][]byte
+
+// writer returns a writer that keeps the generated files in memory, writing
+// the same path twice is an error as one of the files would be lost, e.g.
+// two packages of the same name read from different Rego directories
+func (p pages) writer() writer {
+	return func(path string, content func(io.Writer) error) error {
+		path = filepath.ToSlash(path)
+		if _, ok := p[path]; ok {
+			return fmt.Errorf("%q is generated more than once", path)
+		}
+
+		var buf bytes.Buffer
+		if err := content(&buf); err != nil {
+			return fmt.Errorf("rendering %q: %w", path, err)
+		}
+
+		p[path] = buf.Bytes()
+
+		return nil
+	}
+}
+
+// paths returns the sorted paths of the generated files
+func (p pages) paths() []string {
+	paths := make([]string, 0, len(p))
+	for path := range p {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+// manifest returns the content of the manifest listing the generated files
+func (p pages) manifest() []byte {
+	var buf bytes.Buffer
+	buf.WriteString(manifestHeader)
+	for _, path := range p.paths() {
+		buf.WriteString(path)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes()
+}
+
+// stale returns the files listed in the manifest found in the output
+// directory that are no longer generated
+func (p pages) stale(dir string) ([]string, error) {
+	previous, err := readManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	stale := make([]string, 0, 5)
+	for _, path := range previous {
+		if _, ok := p[path]; !ok {
+			stale = append(stale, path)
+		}
+	}
+
+	return stale, nil
+}
+
+// sync writes the generated files, and the manifest, into the output
+// directory and removes the files that are no longer generated. Files with
+// unchanged content are not rewritten.
+func (p pages) sync(dir string) error {
+	stale, err := p.stale(dir)
+	if err != nil {
+		return err
+	}
+
+	write := func(path string, content []byte) error {
+		file := filepath.Join(dir, filepath.FromSlash(path))
+		if existing, err := os.ReadFile(file); err == nil && bytes.Equal(existing, content) {
+			return nil
+		}
+
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return fmt.Errorf("creating directory for %q: %w", file, err)
+		}
+
+		if err := os.WriteFile(file, content, 0644); err != nil {
+			return fmt.Errorf("writing file %q: %w", file, err)
+		}
+
+		return nil
+	}
+
+	for _, path := range p.paths() {
+		if err := write(path, p[path]); err != nil {
+			return err
+		}
+	}
+
+	for _, path := range stale {
+		if err := remove(dir, path); err != nil {
+			return err
+		}
+	}
+
+	return write(manifestFile, p.manifest())
+}
+
+// remove deletes the file and any of its parent directories, within the
+// output directory, left empty
+func remove(dir, path string) error {
+	file := filepath.Join(dir, filepath.FromSlash(path))
+	if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("removing stale file %q: %w", file, err)
+	}
+
+	for d := filepath.Dir(file); d != filepath.Clean(dir); d = filepath.Dir(d) {
+		entries, err := os.ReadDir(d)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+
+		if err := os.Remove(d); err != nil {
+			return fmt.Errorf("removing empty directory %q: %w", d, err)
+		}
+	}
+
+	return nil
+}
+
+// diff returns the unified diff between the files in the output directory and
+// the generated files, including the removal of the files no longer generated
+// and the changes to the manifest. The diff is empty if the output directory
+// is up to date.
+func (p pages) diff(dir string) (string, error) {
+	stale, err := p.stale(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	compare := func(path string, generated []byte, removed bool) error {
+		existing, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		exists := err == nil
+
+		if exists && !removed && bytes.Equal(existing, generated) {
+			return nil
+		}
+
+		from, to := "a/"+path, "b/"+path
+		if !exists {
+			from = "/dev/null"
+		}
+		if removed {
+			to = "/dev/null"
+		}
+
+		d, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        lines(existing),
+			B:        lines(generated),
+			FromFile: from,
+			ToFile:   to,
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		b.WriteString(d)
+
+		return nil
+	}
+
+	for _, path := range p.paths() {
+		if err := compare(path, p[path], false); err != nil {
+			return "", err
+		}
+	}
+
+	for _, path := range stale {
+		if err := compare(path, nil, true); err != nil {
+			return "", err
+		}
+	}
+
+	if err := compare(manifestFile, p.manifest(), false); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// lines splits the content into lines keeping the line endings
+func lines(content []byte) []string {
+	l := strings.SplitAfter(string(content), "\n")
+	if l[len(l)-1] == "" {
+		l = l[:len(l)-1]
+	}
+
+	return l
+}
+
+// readManifest returns the paths listed in the manifest in the output
+// directory, none if there is no manifest
+func readManifest(dir string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading the manifest of generated files: %w", err)
+	}
+
+	paths := make([]string, 0, 50)
+	s := bufio.NewScanner(bytes.NewReader(data))
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// never remove files outside of the output directory
+		clean := path.Clean(line)
+		if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+			continue
+		}
+
+		paths = append(paths, clean)
+	}
+
+	return paths, s.Err()
+}
@@ -0,0 +1,263 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readFiles returns the content of all files in the directory keyed by their
+// slash separated path relative to the directory
+func readFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = string(data)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return files
+}
+
+func assertFiles(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+
+	got := readFiles(t, dir)
+	for path, content := range want {
+		if c, ok := got[path]; !ok {
+			t.Errorf("missing file %s", path)
+		} else if c != content {
+			t.Errorf("got content %q of %s, want %q", c, path, content)
+		}
+	}
+
+	for path := range got {
+		if _, ok := want[path]; !ok {
+			t.Errorf("unexpected file %s", path)
+		}
+	}
+}
+
+func TestSyncFirstRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "nav.adoc", "written by hand\n")
+
+	p := pages{"pages/a.adoc": []byte("a\n"), "partials/b.adoc": []byte("b\n")}
+	if err := p.sync(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFiles(t, dir, map[string]string{
+		"nav.adoc":        "written by hand\n",
+		"pages/a.adoc":    "a\n",
+		"partials/b.adoc": "b\n",
+		manifestFile:      manifestHeader + "pages/a.adoc\npartials/b.adoc\n",
+	})
+}
+
+func TestSyncPrunesStaleFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := (pages{"pages/a.adoc": []byte("a\n"), "pages/packages/b.adoc": []byte("b\n")}).sync(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (pages{"pages/a.adoc": []byte("a\n")}).sync(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFiles(t, dir, map[string]string{
+		"pages/a.adoc": "a\n",
+		manifestFile:   manifestHeader + "pages/a.adoc\n",
+	})
+
+	// the directory left empty is removed as well
+	if _, err := os.Stat(filepath.Join(dir, "pages", "packages")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the empty directory to be removed, got %v", err)
+	}
+}
+
+func TestSyncKeepsFilesNotInManifest(t *testing.T) {
+	parent := t.TempDir()
+	dir := filepath.Join(parent, "out")
+	writeFile(t, parent, "outside.adoc", "outside\n")
+	writeFile(t, dir, "pages/hand.adoc", "written by hand\n")
+	writeFile(t, dir, "pages/old.adoc", "old\n")
+	writeFile(t, dir, manifestFile, manifestHeader+"pages/old.adoc\n../outside.adoc\n"+filepath.Join(parent, "outside.adoc")+"\n")
+
+	if err := (pages{}).sync(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFiles(t, dir, map[string]string{
+		"pages/hand.adoc": "written by hand\n",
+		manifestFile:      manifestHeader,
+	})
+
+	if _, err := os.Stat(filepath.Join(parent, "outside.adoc")); err != nil {
+		t.Errorf("expected the file outside of the output directory to be kept, got %v", err)
+	}
+}
+
+func TestSyncKeepsUnchangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	p := pages{"pages/a.adoc": []byte("a\n")}
+	if err := p.sync(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(dir, "pages", "a.adoc")
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(file, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.sync(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !info.ModTime().Equal(old) {
+		t.Errorf("expected the unchanged file not to be rewritten, modified at %s", info.ModTime())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	existing := pages{
+		"pages/changed.adoc": []byte("one\ntwo\n"),
+		"pages/removed.adoc": []byte("removed\n"),
+		"pages/same.adoc":    []byte("same\n"),
+	}
+	if err := existing.sync(dir); err != nil {
+		t.Fatal(err)
+	}
+	before := readFiles(t, dir)
+
+	d, err := existing.diff(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != "" {
+		t.Errorf("expected no differences, got:\n%s", d)
+	}
+
+	generated := pages{
+		"pages/added.adoc":   []byte("added\n"),
+		"pages/changed.adoc": []byte("one\nthree\n"),
+		"pages/same.adoc":    []byte("same\n"),
+	}
+
+	d, err = generated.diff(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"--- /dev/null",
+		"+++ b/pages/added.adoc",
+		"@@ -0,0 +1 @@",
+		"+added",
+		"--- a/pages/changed.adoc",
+		"+++ b/pages/changed.adoc",
+		"@@ -1,2 +1,2 @@",
+		" one",
+		"-two",
+		"+three",
+		"--- a/pages/removed.adoc",
+		"+++ /dev/null",
+		"@@ -1 +0,0 @@",
+		"-removed",
+		"--- a/" + manifestFile,
+		"+++ b/" + manifestFile,
+		"@@ -1,4 +1,4 @@",
+		" " + strings.TrimSuffix(manifestHeader, "\n"),
+		"+pages/added.adoc",
+		" pages/changed.adoc",
+		"-pages/removed.adoc",
+		" pages/same.adoc",
+		"",
+	}, "\n")
+
+	if d != want {
+		t.Errorf("got diff:\n%s\nwant:\n%s", d, want)
+	}
+
+	// checking does not modify the output directory
+	after := readFiles(t, dir)
+	if len(after) != len(before) {
+		t.Errorf("expected the output directory not to change, got %v", after)
+	}
+	for path, content := range before {
+		if after[path] != content {
+			t.Errorf("expected %s not to change, got %q", path, after[path])
+		}
+	}
+}
+
+func TestWriterRejectsDuplicatePaths(t *testing.T) {
+	p := pages{}
+	w := p.writer()
+
+	content := func(s string) func(io.Writer) error {
+		return func(w io.Writer) error {
+			_, err := io.WriteString(w, s)
+			return err
+		}
+	}
+
+	if err := w(filepath.Join("pages", "a.adoc"), content("first\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w("pages/a.adoc", content("second\n")); err == nil {
+		t.Error("expected an error writing the same path twice")
+	}
+
+	if got := string(p["pages/a.adoc"]); got != "first\n" {
+		t.Errorf("got %q, want the first file to be kept", got)
+	}
+}
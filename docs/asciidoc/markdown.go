@@ -0,0 +1,147 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	_ "embed"
+	"path/filepath"
+	"strings"
+)
+
+//go:embed summary.md.template
+var markdownSummaryTemplateText string
+
+//go:embed policy.md.template
+var markdownPolicyTemplateText string
+
+//go:embed package.md.template
+var markdownPackageTemplateText string
+
+//go:embed collection.md.template
+var markdownCollectionTemplateText string
+
+//go:embed timeline.md.template
+var markdownTimelineTemplateText string
+
+//go:embed collection_matrix.md.template
+var markdownCollectionMatrixTemplateText string
+
+//go:embed library.md.template
+var markdownLibraryTemplateText string
+
+//go:embed library_package.md.template
+var markdownLibraryPackageTemplateText string
+
+// markdownRenderer renders Markdown suitable for MkDocs or a GitHub wiki: a
+// SUMMARY.md with the navigation for all policy kinds, a policy page for each
+// policy kind, a page for each package and for each collection, and the
+// timeline of the rules' effective dates. Anchors are emitted as HTML
+// elements so links to rules work regardless of how the Markdown processor
+// generates heading identifiers.
+type markdownRenderer struct{}
+
+func (markdownRenderer) render(w writer, t templateSet, docs []doc) error {
+	if err := w("SUMMARY.md", execute(t["summary.md.template"], docs)); err != nil {
+		return err
+	}
+
+	for _, d := range docs {
+		if err := w(d.Qualifier+"_policy.md", execute(t["policy.md.template"], d)); err != nil {
+			return err
+		}
+
+		for _, p := range *d.Packages {
+			path := filepath.Join("packages", p.Origin+"_"+packageName(&p)+".md")
+			if err := w(path, execute(t["package.md.template"], &p)); err != nil {
+				return err
+			}
+		}
+
+		if d.Collections == nil {
+			continue
+		}
+
+		for _, c := range *d.Collections {
+			path := filepath.Join("collections", d.Qualifier+"_"+c.Name()+".md")
+			if err := w(path, execute(t["collection.md.template"], c)); err != nil {
+				return err
+			}
+		}
+	}
+
+	matrices, err := collectionMatrices(docs)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range matrices {
+		if err := w(matrixPage(m.Kind)+".md", execute(t["collection_matrix.md.template"], m)); err != nil {
+			return err
+		}
+	}
+
+	rules, err := effectiveRules(docs)
+	if err != nil {
+		return err
+	}
+
+	return w(timelinePage+".md", execute(t["timeline.md.template"], rules))
+}
+
+func (markdownRenderer) renderLibrary(w writer, t templateSet, packages []libPackage) error {
+	if err := w(libraryPage+".md", execute(t["library.md.template"], packages)); err != nil {
+		return err
+	}
+
+	for _, p := range packages {
+		if err := w(filepath.Join(libraryPage, p.Page()+".md"), execute(t["library_package.md.template"], p)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (markdownRenderer) templates() map[string]string {
+	return map[string]string{
+		"summary.md.template":           markdownSummaryTemplateText,
+		"policy.md.template":            markdownPolicyTemplateText,
+		"package.md.template":           markdownPackageTemplateText,
+		"collection.md.template":        markdownCollectionTemplateText,
+		"timeline.md.template":          markdownTimelineTemplateText,
+		"collection_matrix.md.template": markdownCollectionMatrixTemplateText,
+		"library.md.template":           markdownLibraryTemplateText,
+		"library_package.md.template":   markdownLibraryPackageTemplateText,
+	}
+}
+
+func (markdownRenderer) assetPath(name string) string {
+	return name
+}
+
+// sourceURL is empty, the Markdown pages are published outside of this
+// repository and only link to the source given a base, see
+// Options.SourceURL
+func (markdownRenderer) sourceURL() string {
+	return ""
+}
+
+// cell makes the text safe to be placed within a Markdown table cell
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,227 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// policyTree writes a small policy tree to a temporary directory and returns
+// the directory: a release package with a failure and a warning, two release
+// collections and a task package
+func policyTree(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeFile(t, dir, "policy/release/a/a.rego", `# METADATA
+# title: A
+# description: Checks a | b.
+package a
+
+import rego.v1
+
+# METADATA
+# title: Rule one
+# description: The first rule.
+# custom:
+#   short_name: one
+#   failure_msg: One failed
+#   collections:
+#   - minimal
+#   - strict
+#   effective_on: 2025-05-01T00:00:00Z
+deny contains "one" if {
+	true
+}
+
+# METADATA
+# title: Rule two
+# description: The second rule.
+# custom:
+#   short_name: two
+#   failure_msg: Two failed
+#   depends_on:
+#   - a.one
+#   collections:
+#   - strict
+warn contains "two" if {
+	true
+}
+`)
+	writeFile(t, dir, "policy/release/collection/minimal/minimal.rego", `# METADATA
+# title: minimal
+# description: The minimal rules.
+package collection.minimal
+`)
+	writeFile(t, dir, "policy/release/collection/strict/strict.rego", `# METADATA
+# title: strict
+# description: All the rules.
+package collection.strict
+`)
+	writeFile(t, dir, "policy/task/b/b.rego", `# METADATA
+# title: B
+# description: Checks tasks.
+package b
+
+import rego.v1
+
+# METADATA
+# title: Rule three
+# description: The third rule.
+# custom:
+#   short_name: three
+#   failure_msg: Three failed
+#   effective_on: 2025-07-01T00:00:00Z
+deny contains "three" if {
+	true
+}
+`)
+
+	return dir
+}
+
+func TestMarkdownRenderer(t *testing.T) {
+	dir := policyTree(t)
+
+	cases := []struct {
+		name string
+		opts Options
+		// want holds lines expected in the pages
+		want map[string][]string
+		// absent holds text not expected in the pages
+		absent map[string][]string
+	}{
+		{
+			name: "pages",
+			opts: Options{Format: "markdown"},
+			want: map[string][]string{
+				"SUMMARY.md": {
+					"* [Release Policy](release_policy.md)",
+					"    * [strict](collections/release_strict.md)",
+					"    * [Rule two](packages/release_a.md#a__two)",
+					"    * [Rule three](packages/task_b.md#b__three)",
+				},
+				"release_policy.md": {
+					"| [a](packages/release_a.md) | Checks a \\| b. |",
+					"| <a id=\"minimal\"></a>[`minimal`](collections/release_minimal.md) | The minimal rules. | 1 |",
+				},
+				"packages/release_a.md": {
+					`<a id="a__one"></a>`,
+					"### [Rule one](#a__one)",
+					"* Required by: [`a.two`](release_a.md#a__two)",
+					"* Depends on: [`a.one`](release_a.md#a__one)",
+				},
+				"collections/release_strict.md": {
+					"        - '@strict'",
+					"* [Rule two](../packages/release_a.md#a__two) (warning)",
+				},
+				"timeline.md": {
+					"| 2025-07-01 | **FAILURE** | [Rule three](packages/task_b.md#b__three) (`b.three`) | [Task](task_policy.md) |  |",
+				},
+			},
+			absent: map[string][]string{
+				"packages/release_a.md": {"[Source]"},
+			},
+		},
+		{
+			name: "source links",
+			opts: Options{Format: "markdown", SourceURL: "https://example.com/policy/blob/main/"},
+			want: map[string][]string{
+				"packages/release_a.md": {
+					"* [Source](https://example.com/policy/blob/main/policy/release/a/a.rego#L8)",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			p, err := generate(c.opts, []string{dir})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for path, lines := range c.want {
+				page, ok := p[path]
+				if !ok {
+					t.Errorf("missing page %s, got %v", path, p.paths())
+					continue
+				}
+				for _, l := range lines {
+					if !slices.Contains(strings.Split(string(page), "\n"), l) {
+						t.Errorf("missing line %q in %s:\n%s", l, path, page)
+					}
+				}
+			}
+
+			for path, texts := range c.absent {
+				for _, s := range texts {
+					if strings.Contains(string(p[path]), s) {
+						t.Errorf("unexpected %q in %s:\n%s", s, path, p[path])
+					}
+				}
+			}
+		})
+	}
+
+	t.Run("page names", func(t *testing.T) {
+		p, err := generate(Options{Format: "markdown"}, []string{dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := []string{
+			"SUMMARY.md",
+			"collections/release_minimal.md",
+			"collections/release_strict.md",
+			"library.md",
+			"packages/release_a.md",
+			"packages/task_b.md",
+			"release_collection_matrix.csv",
+			"release_collection_matrix.json",
+			"release_collection_matrix.md",
+			"release_policy.md",
+			"task_policy.md",
+			"timeline.md",
+		}
+		if got := p.paths(); !slices.Equal(got, want) {
+			t.Errorf("got pages %v, want %v", got, want)
+		}
+	})
+}
+
+func TestCell(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "plain", text: "text", want: "text"},
+		{name: "pipe", text: "a | b", want: `a \| b`},
+		{name: "new lines", text: "a\nb\n\n  c\n", want: "a b c"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := cell(c.text); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
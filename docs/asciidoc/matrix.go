@@ -0,0 +1,226 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// matrixPage returns the name, without the extension, of the page comparing
+// the rule collections of the policy kind with the given qualifier, the CSV
+// and JSON exports are named alike
+func matrixPage(qualifier string) string {
+	return qualifier + "_collection_matrix"
+}
+
+// collectionMatrix lists the rules of a policy kind against its collections
+type collectionMatrix struct {
+	// Kind is the qualifier of the policy kind, e.g. release
+	Kind        string             `json:"policy"`
+	KindName    string             `json:"-"`
+	Collections []matrixCollection `json:"collections"`
+	Rules       []matrixRule       `json:"rules"`
+	// Differences holds, for each pair of collections, the rules in one
+	// collection but not in the other, pairs without differences are omitted
+	Differences []matrixDifference `json:"differences"`
+}
+
+// matrixCollection identifies a column of the matrix
+type matrixCollection struct {
+	// Name is the name of the collection's package, e.g. rhtap_multi_ci
+	Name string `json:"name"`
+	// Title is the name the rules use to list the collection, e.g.
+	// rhtap-multi-ci
+	Title string `json:"title"`
+}
+
+// matrixRule is a rule and the collections it is included in
+type matrixRule struct {
+	// Code is the package name and the rule's short name, e.g. tasks.required_tasks_found
+	Code  string `json:"code"`
+	Title string `json:"title"`
+	// Type is either failure or warning
+	Type string `json:"type"`
+	// In holds whether the rule is included in each of the collections, keyed
+	// by the collection's title
+	In map[string]bool `json:"collections"`
+	// Page is the path of the page documenting the rule, relative to the
+	// pages directory and without the extension
+	Page   string `json:"-"`
+	Anchor string `json:"-"`
+}
+
+// matrixDifference holds the rules included in a collection but not in the
+// other collection
+type matrixDifference struct {
+	Collection string       `json:"collection"`
+	Other      string       `json:"not_in"`
+	Rules      []matrixRule `json:"-"`
+	// Codes holds the codes of the rules, for the JSON export
+	Codes []string `json:"rules"`
+}
+
+// Anchor returns the anchor of the difference within the matrix page
+func (d matrixDifference) Anchor() string {
+	return fmt.Sprintf("in_%s_not_in_%s", d.Collection, d.Other)
+}
+
+// Difference returns the rules in the collection but not in the other
+// collection, nil if there are none
+func (m collectionMatrix) Difference(collection, other string) *matrixDifference {
+	for _, d := range m.Differences {
+		if d.Collection == collection && d.Other == other {
+			return &d
+		}
+	}
+
+	return nil
+}
+
+// collectionMatrices builds the matrix of each of the policy kinds with
+// rule collections, from the rules included in each collection
+func collectionMatrices(docs []doc) ([]collectionMatrix, error) {
+	matrices := make([]collectionMatrix, 0, len(docs))
+	for _, d := range docs {
+		if d.Collections == nil {
+			continue
+		}
+
+		m := collectionMatrix{
+			Kind:        d.Qualifier,
+			KindName:    d.Name,
+			Collections: make([]matrixCollection, 0, len(*d.Collections)),
+			Rules:       make([]matrixRule, 0, 100),
+			Differences: make([]matrixDifference, 0, 10),
+		}
+
+		included := map[*ast.Annotations]map[string]bool{}
+		for _, c := range *d.Collections {
+			m.Collections = append(m.Collections, matrixCollection{Name: c.Name(), Title: c.Title})
+			for _, r := range *c.Rules {
+				if included[r] == nil {
+					included[r] = map[string]bool{}
+				}
+				included[r][c.Title] = true
+			}
+		}
+
+		for _, p := range *d.Packages {
+			for _, a := range *p.Rules {
+				typ, err := warningOrFailure(a)
+				if err != nil {
+					return nil, err
+				}
+
+				anchor, err := anchor(a)
+				if err != nil {
+					return nil, err
+				}
+
+				in := make(map[string]bool, len(m.Collections))
+				for _, c := range m.Collections {
+					in[c.Title] = included[a][c.Title]
+				}
+
+				name := packageName(&p)
+				m.Rules = append(m.Rules, matrixRule{
+					Code:   fmt.Sprintf("%s.%s", name, a.Custom["short_name"]),
+					Title:  a.Title,
+					Type:   typ,
+					In:     in,
+					Page:   "packages/" + p.Origin + "_" + name,
+					Anchor: anchor,
+				})
+			}
+		}
+
+		sort.Slice(m.Rules, func(i, j int) bool {
+			return m.Rules[i].Code < m.Rules[j].Code
+		})
+
+		for _, c := range m.Collections {
+			for _, other := range m.Collections {
+				if c == other {
+					continue
+				}
+
+				diff := matrixDifference{Collection: c.Title, Other: other.Title, Codes: []string{}}
+				for _, r := range m.Rules {
+					if r.In[c.Title] && !r.In[other.Title] {
+						diff.Rules = append(diff.Rules, r)
+						diff.Codes = append(diff.Codes, r.Code)
+					}
+				}
+
+				if len(diff.Rules) > 0 {
+					m.Differences = append(m.Differences, diff)
+				}
+			}
+		}
+
+		matrices = append(matrices, m)
+	}
+
+	return matrices, nil
+}
+
+// writeMatrixCSV writes a row for each rule with its code, title and type,
+// and a column for each collection marked with an x if the rule is included
+func writeMatrixCSV(m collectionMatrix) func(io.Writer) error {
+	return func(w io.Writer) error {
+		c := csv.NewWriter(w)
+		header := []string{"code", "title", "type"}
+		for _, col := range m.Collections {
+			header = append(header, col.Title)
+		}
+
+		if err := c.Write(header); err != nil {
+			return err
+		}
+
+		for _, r := range m.Rules {
+			row := []string{r.Code, r.Title, r.Type}
+			for _, col := range m.Collections {
+				mark := ""
+				if r.In[col.Title] {
+					mark = "x"
+				}
+				row = append(row, mark)
+			}
+
+			if err := c.Write(row); err != nil {
+				return err
+			}
+		}
+
+		c.Flush()
+
+		return c.Error()
+	}
+}
+
+// writeMatrixJSON writes the matrix as indented JSON
+func writeMatrixJSON(m collectionMatrix) func(io.Writer) error {
+	return func(w io.Writer) error {
+		return WriteJSON(w, m)
+	}
+}
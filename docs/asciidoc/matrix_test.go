@@ -0,0 +1,142 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"strings"
+	"testing"
+)
+
+// matrixModel loads the policyTree with a collection whose title differs
+// from its package name, and a rule in none of the collections
+func matrixModel(t *testing.T) *model {
+	t.Helper()
+
+	dir := policyTree(t)
+	writeFile(t, dir, "policy/release/collection/multi_ci/multi_ci.rego", `# METADATA
+# title: multi-ci
+# description: The rules for many CI systems.
+package collection.multi_ci
+`)
+	writeFile(t, dir, "policy/release/c/c.rego", "# METADATA\n# title: C\n"+conventionsModule("c",
+		"short_name: ci\ncollections:\n- multi-ci",
+		"short_name: none"))
+
+	m, err := load(nil, []string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return m
+}
+
+func TestCollectionMatrices(t *testing.T) {
+	matrices, err := collectionMatrices(matrixModel(t).docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the task policy has no collections
+	if len(matrices) != 1 || matrices[0].Kind != "release" {
+		t.Fatalf("got matrices %v, want only the release matrix", matrices)
+	}
+	m := matrices[0]
+
+	var csv strings.Builder
+	if err := writeMatrixCSV(m)(&csv); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := `code,title,type,minimal,multi-ci,strict
+a.one,Rule one,failure,x,,x
+a.two,Rule two,warning,,,x
+c.ci,A rule,failure,,x,
+c.none,A rule,failure,,,
+`
+	if csv.String() != wantCSV {
+		t.Errorf("got CSV:\n%s\nwant:\n%s", csv.String(), wantCSV)
+	}
+
+	var json strings.Builder
+	if err := writeMatrixJSON(m)(&json); err != nil {
+		t.Fatal(err)
+	}
+	assertJSON(t, "matrix", json.String(), `{
+		"policy": "release",
+		"collections": [
+			{"name": "minimal", "title": "minimal"},
+			{"name": "multi_ci", "title": "multi-ci"},
+			{"name": "strict", "title": "strict"}
+		],
+		"rules": [
+			{"code": "a.one", "title": "Rule one", "type": "failure", "collections": {"minimal": true, "multi-ci": false, "strict": true}},
+			{"code": "a.two", "title": "Rule two", "type": "warning", "collections": {"minimal": false, "multi-ci": false, "strict": true}},
+			{"code": "c.ci", "title": "A rule", "type": "failure", "collections": {"minimal": false, "multi-ci": true, "strict": false}},
+			{"code": "c.none", "title": "A rule", "type": "failure", "collections": {"minimal": false, "multi-ci": false, "strict": false}}
+		],
+		"differences": [
+			{"collection": "minimal", "not_in": "multi-ci", "rules": ["a.one"]},
+			{"collection": "multi-ci", "not_in": "minimal", "rules": ["c.ci"]},
+			{"collection": "multi-ci", "not_in": "strict", "rules": ["c.ci"]},
+			{"collection": "strict", "not_in": "minimal", "rules": ["a.two"]},
+			{"collection": "strict", "not_in": "multi-ci", "rules": ["a.one", "a.two"]}
+		]
+	}`)
+}
+
+func TestMatrixDifference(t *testing.T) {
+	matrices, err := collectionMatrices(matrixModel(t).docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := matrices[0]
+
+	cases := []struct {
+		collection, other string
+		// want holds the codes of the rules, nil if there is no difference
+		want   []string
+		anchor string
+	}{
+		{collection: "strict", other: "minimal", want: []string{"a.two"}, anchor: "in_strict_not_in_minimal"},
+		{collection: "strict", other: "multi-ci", want: []string{"a.one", "a.two"}, anchor: "in_strict_not_in_multi-ci"},
+		{collection: "minimal", other: "strict"},
+		{collection: "minimal", other: "unknown"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.collection+" "+c.other, func(t *testing.T) {
+			d := m.Difference(c.collection, c.other)
+			if c.want == nil {
+				if d != nil {
+					t.Errorf("got difference %v, want none", d.Codes)
+				}
+				return
+			}
+
+			if d == nil {
+				t.Fatalf("got no difference, want %v", c.want)
+			}
+
+			if strings.Join(d.Codes, " ") != strings.Join(c.want, " ") || len(d.Rules) != len(c.want) {
+				t.Errorf("got difference %v, want %v", d.Codes, c.want)
+			}
+
+			if d.Anchor() != c.anchor {
+				t.Errorf("got anchor %q, want %q", d.Anchor(), c.anchor)
+			}
+		})
+	}
+}
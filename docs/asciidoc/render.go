@@ -0,0 +1,156 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// renderer renders the documentation model in a particular output format
+type renderer interface {
+	// render creates the pages documenting the given policy kinds using the
+	// writer and the templates
+	render(w writer, t templateSet, docs []doc) error
+	// renderLibrary creates the pages documenting the library packages using
+	// the writer and the templates
+	renderLibrary(w writer, t templateSet, packages []libPackage) error
+	// templates returns the text of the embedded templates keyed by their
+	// file name
+	templates() map[string]string
+	// assetPath returns the path, relative to the output directory, where a
+	// file that is not a page, e.g. the rule catalog, with the given name is
+	// placed
+	assetPath(name string) string
+	// sourceURL returns the default base of the links to the source of the
+	// files in the unnamed Rego directories that do not declare one, empty if
+	// they are not linked to by default
+	sourceURL() string
+}
+
+// renderers holds the supported output formats
+var renderers = map[string]renderer{
+	"asciidoc": asciidocRenderer{},
+	"markdown": markdownRenderer{},
+}
+
+// Formats returns the names of the supported output formats
+func Formats() []string {
+	formats := make([]string, 0, len(renderers))
+	for f := range renderers {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+
+	return formats
+}
+
+// writer creates a page at the path, relative to the output directory, with
+// the content produced by the given function, see pages.writer
+type writer func(path string, content func(io.Writer) error) error
+
+// execute returns a function that renders the template with the given data
+func execute(t *template.Template, data any) func(io.Writer) error {
+	return func(w io.Writer) error {
+		return t.Execute(w, data)
+	}
+}
+
+// templateSet holds the parsed templates of a renderer keyed by their file
+// name
+type templateSet map[string]*template.Template
+
+// templateExtension is the extension of the template files that can be placed
+// in the template override directory
+const templateExtension = ".template"
+
+// loadTemplates parses the renderer's embedded templates, or, when the
+// override directory, if given, contains a file with the same name, the
+// template from that file. In addition to the helper functions available to
+// the embedded templates, the docs function returns all documented policy
+// kinds, i.e. the whole documentation model, the source function returns the
+// link to a row of a documented file, empty if it is not linked to, and the
+// policyOrigin function returns the policy kind of a package or a rule.
+func loadTemplates(r renderer, dir string, docs []doc, src sources) (templateSet, error) {
+	texts := r.templates()
+
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("reading the template directory: %w", err)
+		}
+
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || !strings.HasSuffix(name, templateExtension) {
+				continue
+			}
+
+			if _, ok := texts[name]; !ok {
+				return nil, fmt.Errorf("unknown template %q in %s, expecting one of: %s", name, dir, strings.Join(templateNames(r), ", "))
+			}
+
+			text, err := os.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				return nil, fmt.Errorf("reading template %q: %w", name, err)
+			}
+			texts[name] = string(text)
+		}
+	}
+
+	f := template.FuncMap{
+		"docs": func() []doc {
+			return docs
+		},
+		"source": src.link,
+		"policyOrigin": func(a *ast.Annotations) string {
+			return src.origins.kind(a.Location.File)
+		},
+	}
+	for k, v := range funcs {
+		f[k] = v
+	}
+
+	t := templateSet{}
+	for name, text := range texts {
+		parsed, err := template.New(name).Funcs(f).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("parsing template %q: %w", name, err)
+		}
+		t[name] = parsed
+	}
+
+	return t, nil
+}
+
+// templateNames returns the sorted names of the renderer's templates
+func templateNames(r renderer) []string {
+	names := make([]string, 0, 4)
+	for name := range r.templates() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,120 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateOverrides(t *testing.T) {
+	rego := policyTree(t)
+
+	cases := []struct {
+		name   string
+		format string
+		// templates are the files in the template override directory
+		templates map[string]string
+		// page is the page expected to have the content want
+		page string
+		want string
+		err  string
+	}{
+		{
+			name:   "override",
+			format: "asciidoc",
+			templates: map[string]string{
+				"package.template":  `{{ packageName . }} of {{ len docs }} kinds{{ range .Rules }}, {{ policyOrigin . }} {{ anchor . }} {{ source .Location.File .Location.Row }}{{ end }}`,
+				"README.md":         "not a template",
+				"nested/a.template": "not in the directory",
+			},
+			page: "pages/packages/release_a.adoc",
+			want: "a of 2 kinds, release a__one https://example.com/policy/release/a/a.rego#L8, release a__two https://example.com/policy/release/a/a.rego#L22",
+		},
+		{
+			name:   "embedded templates are kept",
+			format: "asciidoc",
+			templates: map[string]string{
+				"package.template": "overridden",
+			},
+			page: "pages/task_policy.adoc",
+			want: "= Task Policy",
+		},
+		{
+			name:   "markdown",
+			format: "markdown",
+			templates: map[string]string{
+				"policy.md.template": "# {{ .Name }} rules",
+			},
+			page: "release_policy.md",
+			want: "# Release rules",
+		},
+		{
+			name:   "template of another format",
+			format: "markdown",
+			templates: map[string]string{
+				"package.template": "overridden",
+			},
+			err: `unknown template "package.template"`,
+		},
+		{
+			name:   "invalid template",
+			format: "asciidoc",
+			templates: map[string]string{
+				"nav.template": "{{ .Name ",
+			},
+			err: `parsing template "nav.template"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range c.templates {
+				writeFile(t, dir, name, content)
+			}
+
+			p, err := generate(Options{Format: c.format, Templates: dir, SourceURL: "https://example.com"}, []string{rego})
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected error containing %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			page, ok := p[c.page]
+			if !ok {
+				t.Fatalf("missing page %s, got %v", c.page, p.paths())
+			}
+
+			if got := strings.SplitN(string(page), "\n", 2)[0]; got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := generate(Options{Templates: filepath.Join(t.TempDir(), "missing")}, []string{rego})
+		if err == nil || !strings.Contains(err.Error(), "reading the template directory") {
+			t.Errorf("expected a read error, got %v", err)
+		}
+	})
+}
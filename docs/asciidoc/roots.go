@@ -0,0 +1,188 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// root is a directory the Rego files are read from, given as
+// [name=]path[,kind=KIND][,url=URL], e.g. .. or
+// cli=../cli/docs/policy/release,kind=release,url=https://github.com/conforma/cli/blob/v0.7.95/docs/policy/release
+type root struct {
+	// Name names a root that is not part of this repository, e.g. cli, its
+	// files are not linked to unless the root has a URL
+	Name string
+	Path string
+	// Kind is the policy kind of the files in the root that are not within
+	// its policy directory, the files are documented as if they were in the
+	// directory of the policy kind, i.e. policy/<kind>/
+	Kind string
+	// URL is the base of the links to the source of the files in the root,
+	// when empty the files of unnamed roots are linked to using the base
+	// given to the generator and the files of named roots are not linked to
+	URL string
+}
+
+// parseRoots parses the specification of each Rego directory
+func parseRoots(specs []string) ([]root, error) {
+	roots := make([]root, 0, len(specs))
+	for _, s := range specs {
+		r, err := parseRoot(s)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, r)
+	}
+
+	return roots, nil
+}
+
+// parseRoot parses the [name=]path[,kind=KIND][,url=URL] specification of a
+// Rego directory
+func parseRoot(spec string) (root, error) {
+	parts := strings.Split(spec, ",")
+
+	var r root
+	if name, dir, ok := strings.Cut(parts[0], "="); ok {
+		if name == "" || strings.ContainsAny(name, `/\`) {
+			return root{}, fmt.Errorf("invalid name %q of the Rego directory %q", name, spec)
+		}
+		r.Name, r.Path = name, dir
+	} else {
+		r.Path = parts[0]
+	}
+
+	if r.Path == "" {
+		return root{}, fmt.Errorf("missing path of the Rego directory %q", spec)
+	}
+
+	for _, p := range parts[1:] {
+		k, v, _ := strings.Cut(p, "=")
+		switch k {
+		case "kind":
+			if v == "" || strings.ContainsAny(v, `/\`) {
+				return root{}, fmt.Errorf("invalid kind %q of the Rego directory %q", v, spec)
+			}
+			r.Kind = v
+		case "url":
+			r.URL = strings.TrimSuffix(v, "/")
+		default:
+			return root{}, fmt.Errorf("unknown option %q of the Rego directory %q, expecting one of: kind, url", k, spec)
+		}
+	}
+
+	return r, nil
+}
+
+// file returns the path the file, given relative to the root, is documented
+// at, files of a root with a policy kind outside of its policy directory are
+// placed in the directory of the kind, e.g. builtin/image.rego is documented
+// as policy/release/builtin/image.rego
+func (r root) file(rel string) string {
+	if r.Kind == "" || strings.HasPrefix(rel, "policy/") {
+		return rel
+	}
+
+	return path.Join("policy", r.Kind, rel)
+}
+
+// origin is the root a documented file was read from, along with the path of
+// the file relative to the root
+type origin struct {
+	root root
+	rel  string
+}
+
+// origins records the root each documented file was read from, keyed by the
+// path the file is documented at
+type origins map[string]origin
+
+// add records that the file, read from the root at the path relative to it,
+// is documented at the given path, nothing is recorded by nil origins
+func (o origins) add(file string, r root, rel string) {
+	if o != nil {
+		o[file] = origin{root: r, rel: rel}
+	}
+}
+
+// kind returns the policy kind of the documented file, the kind of the root
+// the file was read from, or, for the files within the policy directory of
+// the root, the directory under policy/ holding the file. Files not read from
+// any root are placed by their path.
+func (o origins) kind(file string) string {
+	rel := file
+	if f, ok := o[file]; ok {
+		if f.root.Kind != "" && !strings.HasPrefix(f.rel, "policy/") {
+			return f.root.Kind
+		}
+		rel = f.rel
+	}
+
+	parts := strings.Split(rel, "/")
+	if len(parts) > 2 && parts[0] == "policy" {
+		return parts[1]
+	}
+
+	return ""
+}
+
+// documented returns the path the file, given by its path on disk, is
+// documented at, or the file itself if it was not read from any root
+func (o origins) documented(file string) string {
+	for d, f := range o {
+		if filepath.Join(f.root.Path, filepath.FromSlash(f.rel)) == file {
+			return d
+		}
+	}
+
+	return file
+}
+
+// sources links the documented files to their source
+type sources struct {
+	origins origins
+	// base is the base of the links to the files of the unnamed roots not
+	// declaring a URL, and of the files not read from any root, these files
+	// are not linked to if empty
+	base string
+}
+
+// link returns the URL of the row within the documented file, empty if the
+// file comes from a named root without a URL, e.g. a root holding the rules
+// built into the CLI, or if there is no base for the file
+func (s sources) link(file string, row int) string {
+	rel := file
+	if o, ok := s.origins[file]; ok {
+		switch {
+		case o.root.URL != "":
+			return fmt.Sprintf("%s/%s#L%d", o.root.URL, o.rel, row)
+		case o.root.Name != "":
+			return ""
+		}
+		rel = o.rel
+	}
+
+	if s.base == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s#L%d", s.base, rel, row)
+}
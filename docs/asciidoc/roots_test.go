@@ -0,0 +1,130 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"testing"
+)
+
+func TestSourcesLink(t *testing.T) {
+	policy, cli, local := t.TempDir(), t.TempDir(), t.TempDir()
+	writeFile(t, policy, "policy/release/a/a.rego", "package policy.release.a\n")
+	writeFile(t, cli, "b/b.rego", "package policy.release.b\n")
+	// the same path within the policy root must not be linked to
+	writeFile(t, policy, "b/b.rego", "package b\n")
+	writeFile(t, local, "c/c.rego", "package policy.task.c\n")
+
+	roots := []root{
+		{Path: policy},
+		{Name: "cli", Path: cli, Kind: "release", URL: "https://example.com/cli"},
+		{Name: "local", Path: local, Kind: "task"},
+	}
+
+	o := origins{}
+	if _, _, err := inspect(roots, o); err != nil {
+		t.Fatal(err)
+	}
+
+	s := sources{origins: o, base: "https://example.com/policy"}
+	cases := []struct {
+		file string
+		want string
+	}{
+		{file: "policy/release/a/a.rego", want: "https://example.com/policy/policy/release/a/a.rego#L3"},
+		{file: "policy/release/b/b.rego", want: "https://example.com/cli/b/b.rego#L3"},
+		{file: "b/b.rego", want: "https://example.com/policy/b/b.rego#L3"},
+		{file: "policy/task/c/c.rego", want: ""},
+		{file: "policy/release/d/d.rego", want: "https://example.com/policy/policy/release/d/d.rego#L3"},
+	}
+
+	for _, c := range cases {
+		if got := s.link(c.file, 3); got != c.want {
+			t.Errorf("got link %q for %s, want %q", got, c.file, c.want)
+		}
+	}
+
+	// without a base only the files of the roots with a URL are linked to
+	s.base = ""
+	if got := s.link("policy/release/a/a.rego", 3); got != "" {
+		t.Errorf("got link %q without a base, want none", got)
+	}
+	if got := s.link("policy/release/b/b.rego", 3); got != "https://example.com/cli/b/b.rego#L3" {
+		t.Errorf("got link %q of a root with a URL, want https://example.com/cli/b/b.rego#L3", got)
+	}
+}
+
+func TestParseRoot(t *testing.T) {
+	cases := []struct {
+		spec string
+		want root
+		err  bool
+	}{
+		{spec: "..", want: root{Path: ".."}},
+		{spec: "..,url=https://example.com/policy/blob/v1/", want: root{Path: "..", URL: "https://example.com/policy/blob/v1"}},
+		{spec: "cli=../cli/docs/policy/release,kind=release,url=https://example.com/cli", want: root{Name: "cli", Path: "../cli/docs/policy/release", Kind: "release", URL: "https://example.com/cli"}},
+		{spec: "../local,kind=task", want: root{Path: "../local", Kind: "task"}},
+		{spec: "=..", err: true},
+		{spec: "a/b=..", err: true},
+		{spec: "cli=", err: true},
+		{spec: "..,kind=", err: true},
+		{spec: "..,kind=a/b", err: true},
+		{spec: "..,ref=main", err: true},
+	}
+
+	for _, c := range cases {
+		got, err := parseRoot(c.spec)
+		if c.err {
+			if err == nil {
+				t.Errorf("expected an error parsing %q, got %+v", c.spec, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error parsing %q: %v", c.spec, err)
+		} else if got != c.want {
+			t.Errorf("got %+v parsing %q, want %+v", got, c.spec, c.want)
+		}
+	}
+}
+
+func TestOriginsKind(t *testing.T) {
+	o := origins{}
+	o.add("policy/release/a/a.rego", root{Path: ".."}, "policy/release/a/a.rego")
+	// the name of the root is not its kind
+	o.add("policy/release/b/b.rego", root{Name: "cli", Path: "cli", Kind: "release"}, "b/b.rego")
+	o.add("c/c.rego", root{Name: "other", Path: "other"}, "c/c.rego")
+	o.add("policy/task/d/d.rego", root{Name: "cli", Path: "cli", Kind: "release"}, "policy/task/d/d.rego")
+
+	cases := []struct {
+		file string
+		want string
+	}{
+		{file: "policy/release/a/a.rego", want: "release"},
+		{file: "policy/release/b/b.rego", want: "release"},
+		{file: "c/c.rego", want: ""},
+		{file: "policy/task/d/d.rego", want: "task"},
+		{file: "policy/pipeline/e/e.rego", want: "pipeline"},
+		{file: "policy/e.rego", want: ""},
+	}
+
+	for _, c := range cases {
+		if got := o.kind(c.file); got != c.want {
+			t.Errorf("got kind %q of %s, want %q", got, c.file, c.want)
+		}
+	}
+}
@@ -0,0 +1,510 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+const (
+	// ruleDataFunction is the helper rules use to read rule data
+	ruleDataFunction = "data.lib.rule_data"
+	// ruleDataDefaults holds the default values of the rule data keys
+	ruleDataDefaults = "data.lib.rule_data_defaults"
+	// ruleDataExample is the example rule data file, relative to the Rego
+	// directory
+	ruleDataExample = "example/data/rule_data.yml"
+)
+
+// ruleDataKey is a rule data key read by a rule
+type ruleDataKey struct {
+	Key string
+	// Default is the JSON encoded default value of the key from
+	// lib.rule_data_defaults, empty if the key has no default
+	Default string
+	// Example is the path of the example rule data file containing the key,
+	// relative to the Rego directory, empty if the key is not in the file
+	Example string
+	// ExampleRow is the row the key is at in the example rule data file
+	ExampleRow int
+}
+
+// ruleData holds the rule data keys read by each rule
+type ruleData struct {
+	keys map[*ast.Annotations][]ruleDataKey
+}
+
+// Keys returns the rule data keys read by the rule with the given annotations
+func (r *ruleData) Keys(a *ast.Annotations) []ruleDataKey {
+	if r == nil {
+		return nil
+	}
+
+	return r.keys[a]
+}
+
+// Names returns the names of the rule data keys read by the rule with the
+// given annotations
+func (r *ruleData) Names(a *ast.Annotations) []string {
+	keys := r.Keys(a)
+	names := make([]string, 0, len(keys))
+	for _, k := range keys {
+		names = append(names, k.Key)
+	}
+
+	return names
+}
+
+// analyseRuleData statically analyses the rules, and all the rules and
+// functions they reference, for calls to lib.rule_data with keys that are
+// constant, i.e. string literals, rules defined as a string literal, or local
+// variables assigned one of those or iterating over a collection of them, e.g.
+// `some key in ["a", "b"]`. The analysis errs on listing too many keys, a key
+// iterated over is listed even if the rule reads only some of the keys at run
+// time, and misses keys it cannot determine, e.g. keys passed as arguments to
+// a function or taken from nested collections. The keys found are joined with
+// their defaults from lib.rule_data_defaults and with their location in the
+// example rule data file found in the Rego directories.
+func analyseRuleData(modules []*ast.Module, annotations []ast.FlatAnnotationsRefSet, roots []root, o origins) (*ruleData, error) {
+	rules := map[string][]*ast.Rule{}
+	for _, m := range modules {
+		for _, r := range m.Rules {
+			p := r.Ref().GroundPrefix().String()
+			rules[p] = append(rules[p], r)
+		}
+	}
+
+	defaults := ruleDataDefaultValues(rules[ruleDataDefaults])
+
+	examples, err := ruleDataExamples(roots, o)
+	if err != nil {
+		return nil, err
+	}
+
+	rd := ruleData{keys: map[*ast.Annotations][]ruleDataKey{}}
+	for _, set := range annotations {
+		for _, ref := range set {
+			if ref.Annotations.Scope != "rule" {
+				continue
+			}
+
+			rule := ref.GetRule()
+			if rule == nil {
+				continue
+			}
+
+			names := ruleDataKeyNames(rules, rule)
+			if len(names) == 0 {
+				continue
+			}
+
+			keys := make([]ruleDataKey, 0, len(names))
+			for _, n := range names {
+				k := ruleDataKey{Key: n, Default: defaults[n]}
+				if row, ok := examples.rows[n]; ok {
+					k.Example = examples.path
+					k.ExampleRow = row
+				}
+				keys = append(keys, k)
+			}
+
+			rd.keys[ref.Annotations] = keys
+		}
+	}
+
+	return &rd, nil
+}
+
+// ruleDataKeyNames returns the sorted names of the constant rule data keys
+// read by the rule, or by any rule it references
+func ruleDataKeyNames(rules map[string][]*ast.Rule, rule *ast.Rule) []string {
+	found := map[string]bool{}
+	visited := map[*ast.Rule]bool{}
+	queue := []*ast.Rule{rule}
+
+	for len(queue) > 0 {
+		r := queue[0]
+		queue = queue[1:]
+		if visited[r] {
+			continue
+		}
+		visited[r] = true
+
+		locals := localStrings(rules, r)
+
+		ast.WalkTerms(r, func(t *ast.Term) bool {
+			var operator *ast.Term
+			var args []*ast.Term
+			if c, ok := t.Value.(ast.Call); ok && len(c) > 0 {
+				operator, args = c[0], c[1:]
+			}
+
+			if operator != nil && resolve(rules, r, operator) == ruleDataFunction && len(args) > 0 {
+				for _, k := range constantStrings(rules, r, locals, args[0]) {
+					found[k] = true
+				}
+			}
+
+			if p := resolve(rules, r, t); p != "" && p != ruleDataFunction {
+				queue = append(queue, rules[p]...)
+			}
+
+			return false
+		})
+
+		// calls as expressions, e.g. `lib.rule_data("key")` on its own
+		// line, are not represented as ast.Call terms
+		ast.WalkExprs(r, func(e *ast.Expr) bool {
+			if !e.IsCall() {
+				return false
+			}
+
+			operands := e.Operands()
+			if len(operands) > 0 && resolve(rules, r, e.OperatorTerm()) == ruleDataFunction {
+				for _, k := range constantStrings(rules, r, locals, operands[0]) {
+					found[k] = true
+				}
+			}
+
+			return false
+		})
+	}
+
+	names := make([]string, 0, len(found))
+	for k := range found {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// resolve returns the path of the rule the term refers to, if it refers to
+// one, taking into account the imports of the rule's module
+func resolve(rules map[string][]*ast.Rule, r *ast.Rule, t *ast.Term) string {
+	base := absoluteRef(r, t)
+	for i := len(base); i > 1; i-- {
+		p := base[:i].String()
+		if _, ok := rules[p]; ok {
+			return p
+		}
+	}
+
+	return ""
+}
+
+// absoluteRef returns the ground prefix of the reference the term is, rooted
+// at data, nil if the term is not a reference
+func absoluteRef(r *ast.Rule, t *ast.Term) ast.Ref {
+	var ref ast.Ref
+	switch v := t.Value.(type) {
+	case ast.Ref:
+		ref = v
+	case ast.Var:
+		ref = ast.Ref{t}
+	default:
+		return nil
+	}
+
+	if len(ref) == 0 {
+		return nil
+	}
+
+	head, ok := ref[0].Value.(ast.Var)
+	if !ok {
+		return nil
+	}
+
+	var base ast.Ref
+	switch {
+	case head.Equal(ast.DefaultRootDocument.Value):
+		base = ref
+	case importAlias(r.Module, head) != nil:
+		base = importAlias(r.Module, head).Concat(ref[1:])
+	default:
+		base = r.Module.Package.Path.Append(ast.StringTerm(string(head))).Concat(ref[1:])
+	}
+
+	return base.GroundPrefix()
+}
+
+// importAlias returns the imported path for the given alias, nil if there is
+// no such import in the module
+func importAlias(m *ast.Module, alias ast.Var) ast.Ref {
+	for _, i := range m.Imports {
+		path, ok := i.Path.Value.(ast.Ref)
+		if !ok || !path.HasPrefix(ast.DefaultRootRef) {
+			continue
+		}
+
+		name := i.Alias
+		if name == "" {
+			last := path[len(path)-1]
+			if s, ok := last.Value.(ast.String); ok {
+				name = ast.Var(s)
+			}
+		}
+
+		if name.Equal(alias) {
+			return path
+		}
+	}
+
+	return nil
+}
+
+// locals holds the strings each local variable of a rule can be assigned,
+// and the strings held by each local variable assigned a collection
+type locals struct {
+	strings map[ast.Var][]string
+	// elements are the values of the collection, keys are the keys of the
+	// object
+	elements map[ast.Var][]string
+	keys     map[ast.Var][]string
+}
+
+// localStrings returns the variables in the rule's body that are assigned
+// constant strings, or collections of them, including the variables
+// iterating over such collections, e.g. `some key in keys`
+func localStrings(rules map[string][]*ast.Rule, r *ast.Rule) locals {
+	l := locals{
+		strings:  map[ast.Var][]string{},
+		elements: map[ast.Var][]string{},
+		keys:     map[ast.Var][]string{},
+	}
+
+	ast.WalkExprs(r, func(e *ast.Expr) bool {
+		if some, ok := e.Terms.(*ast.SomeDecl); ok {
+			for _, s := range some.Symbols {
+				l.iterate(rules, r, s)
+			}
+
+			return false
+		}
+
+		if !e.IsAssignment() && !e.IsEquality() {
+			return false
+		}
+
+		operands := e.Operands()
+		if len(operands) != 2 {
+			return false
+		}
+
+		v, ok := operands[0].Value.(ast.Var)
+		if !ok {
+			return false
+		}
+
+		if s := constantStrings(rules, r, l, operands[1]); len(s) > 0 {
+			l.strings[v] = s
+		}
+
+		if elements, keys := constantCollection(rules, r, l, operands[1]); len(elements) > 0 || len(keys) > 0 {
+			l.elements[v] = elements
+			l.keys[v] = keys
+		}
+
+		return false
+	})
+
+	return l
+}
+
+// iterate records the strings the variables declared by `some x in xs`, or
+// by `some k, v in xs`, can be assigned
+func (l locals) iterate(rules map[string][]*ast.Rule, r *ast.Rule, symbol *ast.Term) {
+	c, ok := symbol.Value.(ast.Call)
+	if !ok || len(c) < 3 {
+		return
+	}
+
+	elements, keys := constantCollection(rules, r, l, c[len(c)-1])
+
+	if v, ok := c[len(c)-2].Value.(ast.Var); ok && len(elements) > 0 {
+		l.strings[v] = elements
+	}
+
+	if len(c) == 4 {
+		if k, ok := c[1].Value.(ast.Var); ok && len(keys) > 0 {
+			l.strings[k] = keys
+		}
+	}
+}
+
+// constantStrings returns the strings the term can be if they can be
+// determined statically
+func constantStrings(rules map[string][]*ast.Rule, r *ast.Rule, l locals, t *ast.Term) []string {
+	switch v := t.Value.(type) {
+	case ast.String:
+		return []string{string(v)}
+	case ast.Var:
+		if s, ok := l.strings[v]; ok {
+			return s
+		}
+	}
+
+	if def := constantRule(rules, r, t); def != nil {
+		if s, ok := def.Head.Value.Value.(ast.String); ok {
+			return []string{string(s)}
+		}
+	}
+
+	return nil
+}
+
+// constantCollection returns the strings held by the collection, and the
+// string keys of the object, the term is if they can be determined
+// statically
+func constantCollection(rules map[string][]*ast.Rule, r *ast.Rule, l locals, t *ast.Term) (elements []string, keys []string) {
+	switch v := t.Value.(type) {
+	case *ast.Array:
+		v.Foreach(func(e *ast.Term) {
+			elements = append(elements, constantStrings(rules, r, l, e)...)
+		})
+	case ast.Set:
+		v.Foreach(func(e *ast.Term) {
+			elements = append(elements, constantStrings(rules, r, l, e)...)
+		})
+	case ast.Object:
+		v.Foreach(func(k, e *ast.Term) {
+			keys = append(keys, constantStrings(rules, r, l, k)...)
+			elements = append(elements, constantStrings(rules, r, l, e)...)
+		})
+	case ast.Var:
+		if _, ok := l.elements[v]; ok {
+			return l.elements[v], l.keys[v]
+		}
+	}
+
+	if elements != nil || keys != nil {
+		return elements, keys
+	}
+
+	if def := constantRule(rules, r, t); def != nil {
+		return constantCollection(rules, def, locals{}, def.Head.Value)
+	}
+
+	return nil, nil
+}
+
+// constantRule returns the rule the term refers to if it is defined once, as
+// a constant, i.e. without arguments, and with a value
+func constantRule(rules map[string][]*ast.Rule, r *ast.Rule, t *ast.Term) *ast.Rule {
+	p := resolve(rules, r, t)
+	if p == "" {
+		return nil
+	}
+
+	defs := rules[p]
+	if len(defs) != 1 || defs[0].Head.Value == nil || len(defs[0].Head.Args) > 0 {
+		return nil
+	}
+
+	// a reference into the rule, e.g. to a key of an object, is not the
+	// rule's value
+	if absoluteRef(r, t).String() != p {
+		return nil
+	}
+
+	return defs[0]
+}
+
+// ruleDataDefaultValues returns the JSON encoded value of each key in
+// lib.rule_data_defaults
+func ruleDataDefaultValues(defs []*ast.Rule) map[string]string {
+	values := map[string]string{}
+	for _, d := range defs {
+		if d.Head.Value == nil {
+			continue
+		}
+
+		obj, ok := d.Head.Value.Value.(ast.Object)
+		if !ok {
+			continue
+		}
+
+		obj.Foreach(func(k, v *ast.Term) {
+			key, ok := k.Value.(ast.String)
+			if !ok {
+				return
+			}
+
+			j, err := ast.JSON(v.Value)
+			if err != nil {
+				return
+			}
+
+			b, err := json.Marshal(j)
+			if err != nil {
+				return
+			}
+
+			values[string(key)] = string(b)
+		})
+	}
+
+	return values
+}
+
+type ruleDataExampleRows struct {
+	path string
+	rows map[string]int
+}
+
+var ruleDataExampleKey = regexp.MustCompile(`^  ([A-Za-z0-9_-]+):`)
+
+// ruleDataExamples finds the row of each key in the example rule data file
+// within the first Rego directory that has one
+func ruleDataExamples(roots []root, o origins) (ruleDataExampleRows, error) {
+	examples := ruleDataExampleRows{rows: map[string]int{}}
+	for _, r := range roots {
+		data, err := os.ReadFile(filepath.Join(r.Path, ruleDataExample))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return examples, fmt.Errorf("reading example rule data: %w", err)
+		}
+
+		examples.path = r.file(ruleDataExample)
+		o.add(examples.path, r, ruleDataExample)
+		s := bufio.NewScanner(bytes.NewReader(data))
+		for row := 1; s.Scan(); row++ {
+			if m := ruleDataExampleKey.FindStringSubmatch(s.Text()); m != nil {
+				if _, ok := examples.rows[m[1]]; !ok {
+					examples.rows[m[1]] = row
+				}
+			}
+		}
+
+		return examples, s.Err()
+	}
+
+	return examples, nil
+}
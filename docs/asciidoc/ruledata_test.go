@@ -0,0 +1,214 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const ruleDataLib = `package lib
+
+import rego.v1
+
+rule_data_defaults := {"a": ["x"], "b": 1}
+
+rule_data(key) := data.rule_data[key]
+`
+
+func TestRuleDataKeyNames(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "string literal",
+			body: `deny contains "x" if {
+	lib.rule_data("a")
+}`,
+			want: []string{"a"},
+		},
+		{
+			name: "call within an assignment",
+			body: `deny contains "x" if {
+	v := lib.rule_data("a")
+	count(v) > 0
+}`,
+			want: []string{"a"},
+		},
+		{
+			name: "local variable",
+			body: `deny contains "x" if {
+	key := "a"
+	lib.rule_data(key)
+}`,
+			want: []string{"a"},
+		},
+		{
+			name: "constant rule",
+			body: `deny contains "x" if {
+	lib.rule_data(_key)
+}
+
+_key := "b"`,
+			want: []string{"b"},
+		},
+		{
+			name: "referenced helper",
+			body: `deny contains "x" if {
+	_helper
+}
+
+_helper if {
+	lib.rule_data("a")
+}`,
+			want: []string{"a"},
+		},
+		{
+			name: "iterating over an array literal",
+			body: `deny contains "x" if {
+	some key in ["a", "b"]
+	lib.rule_data(key)
+}`,
+			want: []string{"a", "b"},
+		},
+		{
+			name: "iterating over a local collection",
+			body: `deny contains "x" if {
+	keys := {"a", "b"}
+	some key in keys
+	lib.rule_data(key)
+}`,
+			want: []string{"a", "b"},
+		},
+		{
+			name: "iterating over the keys of a constant object",
+			body: `deny contains "x" if {
+	some key, _ in _keys
+	lib.rule_data(key)
+}
+
+_keys := {"a": true, "b": false}`,
+			want: []string{"a", "b"},
+		},
+		{
+			name: "reference into a constant object",
+			body: `deny contains "x" if {
+	lib.rule_data(_keys.a)
+}
+
+_keys := {"a": "b"}`,
+			want: []string{},
+		},
+		{
+			name: "function argument is not followed",
+			body: `deny contains "x" if {
+	_check("a")
+}
+
+_check(key) if {
+	lib.rule_data(key)
+}`,
+			want: []string{},
+		},
+		{
+			name: "key from input",
+			body: `deny contains "x" if {
+	lib.rule_data(input.key)
+}`,
+			want: []string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "policy/lib/rule_data.rego", ruleDataLib)
+			writeFile(t, dir, "policy/release/pkg/pkg.rego", `package pkg
+
+import rego.v1
+
+import data.lib
+
+# METADATA
+# title: A rule
+# custom:
+#   short_name: a_rule
+`+c.body+"\n")
+			writeFile(t, dir, ruleDataExample, "rule_data:\n  b: 1\n")
+
+			roots := []root{{Path: dir}}
+			annotations, modules, err := inspect(roots, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rd, err := analyseRuleData(modules, annotations, roots, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []ruleDataKey
+			for _, set := range annotations {
+				for _, ref := range set {
+					if ref.Annotations.Title == "A rule" {
+						got = rd.Keys(ref.Annotations)
+					}
+				}
+			}
+
+			names := make([]string, 0, len(got))
+			for _, k := range got {
+				names = append(names, k.Key)
+			}
+
+			if !slices.Equal(names, c.want) {
+				t.Errorf("got keys %v, want %v", names, c.want)
+			}
+
+			for _, k := range got {
+				switch k.Key {
+				case "a":
+					if k.Default != `["x"]` || k.Example != "" {
+						t.Errorf("got default %q and example %q for key a", k.Default, k.Example)
+					}
+				case "b":
+					if k.Default != "1" || k.Example != ruleDataExample || k.ExampleRow != 2 {
+						t.Errorf("got default %q and example %s:%d for key b", k.Default, k.Example, k.ExampleRow)
+					}
+				}
+			}
+		})
+	}
+}
+
+// writeFile writes the file, creating its directories, within the directory
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	file := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,276 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// watchInterval is how often the Rego directories are checked for changes
+const watchInterval = 500 * time.Millisecond
+
+// watchedExtensions are the extensions of the files that change the
+// documentation: the Rego files, the rule data and the templates
+var watchedExtensions = map[string]bool{
+	".rego":           true,
+	".yml":            true,
+	".yaml":           true,
+	templateExtension: true,
+}
+
+// preview holds the documentation rendered in Markdown for the live preview,
+// along with the error of the last rendering, if any
+type preview struct {
+	mu    sync.RWMutex
+	pages pages
+	err   error
+	// version is incremented on each rendering, so the browser can tell when
+	// to reload the page
+	version int
+}
+
+// Serve renders the documentation of the rules found in the Rego directories
+// and serves it as HTML, converted from the Markdown pages, at the address,
+// e.g. localhost:8000, until the context is done. The Rego directories and the
+// template directory are watched, on change the documentation is rendered
+// again and the pages open in the browser are reloaded. Errors, e.g. in the
+// annotations or the templates, are shown at the top of the pages.
+func Serve(ctx context.Context, addr string, opts Options, rego ...string) error {
+	opts.Format = "markdown"
+	// the library warnings would be repeated on each rendering
+	opts.Warnings = nil
+
+	roots, err := parseRoots(rego)
+	if err != nil {
+		return err
+	}
+
+	watched := make([]string, 0, len(roots)+1)
+	for _, r := range roots {
+		watched = append(watched, r.Path)
+	}
+	if opts.Templates != "" {
+		watched = append(watched, opts.Templates)
+	}
+
+	p := &preview{}
+	p.render(opts, rego)
+
+	go func() {
+		last := fingerprint(watched)
+		t := time.NewTicker(watchInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if f := fingerprint(watched); f != last {
+					last = f
+					p.render(opts, rego)
+				}
+			}
+		}
+	}()
+
+	server := &http.Server{Addr: addr, Handler: p, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// render renders the documentation into memory, keeping the pages of the last
+// successful rendering on error so they can be shown along with the error
+func (p *preview) render(opts Options, rego []string) {
+	generated, err := generate(opts, rego)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err == nil {
+		p.pages = generated
+	}
+	p.err = err
+	p.version++
+}
+
+// fingerprint returns a hash of the path, size and modification time of the
+// watched files within the directories, any change to the files changes it
+func fingerprint(dirs []string) uint64 {
+	h := fnv.New64a()
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// e.g. a file removed while walking, the next check catches up
+				return nil
+			}
+
+			if d.IsDir() {
+				if file != dir && strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !watchedExtensions[filepath.Ext(file)] {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+
+			fmt.Fprintf(h, "%s\x00%d\x00%d\n", file, info.Size(), info.ModTime().UnixNano())
+
+			return nil
+		})
+	}
+
+	return h.Sum64()
+}
+
+// ServeHTTP serves the Markdown pages converted to HTML, the other generated
+// files, e.g. rules.json, as they are, and the version of the rendering for
+// the pages to reload on change
+func (p *preview) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+	switch name {
+	case "":
+		http.Redirect(w, r, "/SUMMARY.md", http.StatusFound)
+		return
+	case "_version":
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = io.WriteString(w, strconv.Itoa(p.version))
+		return
+	}
+
+	content, ok := p.pages[name]
+	if !ok && p.err == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if path.Ext(name) != ".md" {
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", mime.TypeByExtension(path.Ext(name)))
+		_, _ = w.Write(content)
+		return
+	}
+
+	page := previewPage{Title: name, Version: p.version}
+	if p.err != nil {
+		page.Error = p.err.Error()
+	}
+
+	var body bytes.Buffer
+	if err := markdown.Convert(content, &body); err != nil {
+		page.Error = strings.TrimSpace(page.Error + "\n" + err.Error())
+	}
+	// the Markdown is rendered by the embedded, or the overriding, templates
+	// from the annotations in the Rego files being previewed
+	page.Content = template.HTML(body.String())
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := previewTemplate.Execute(w, page); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// markdown converts the pages to HTML, the raw HTML in the pages, e.g. the
+// anchors of the rules, is kept
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+type previewPage struct {
+	Title   string
+	Error   string
+	Content template.HTML
+	Version int
+}
+
+var previewTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: sans-serif; max-width: 70em; margin: 0 auto; padding: 1em; line-height: 1.5; }
+nav { border-bottom: 1px solid #ccc; margin-bottom: 1em; padding-bottom: .5em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: .2em .5em; }
+pre { background: #f6f6f6; padding: .5em; overflow: auto; }
+.error { background: #fdecea; border: 1px solid #d93025; color: #a50e0e; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<nav><a href="/SUMMARY.md">Summary</a> | {{ .Title }}</nav>
+{{- with .Error }}
+<pre class="error">{{ . }}</pre>
+{{- end }}
+{{ .Content }}
+<script>
+(function () {
+  const version = "{{ .Version }}";
+  setInterval(function () {
+    fetch("/_version", { cache: "no-store" })
+      .then(function (r) { return r.text(); })
+      .then(function (v) { if (v !== version) { location.reload(); } })
+      .catch(function () {});
+  }, 1000);
+})();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,206 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPreview(t *testing.T) {
+	dir := policyTree(t)
+	opts := Options{Format: "markdown"}
+
+	p := &preview{}
+	p.render(opts, []string{dir})
+
+	type response struct {
+		status      int
+		contentType string
+		// body holds text expected in the body
+		body []string
+	}
+
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		p.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+		return w
+	}
+
+	check := func(t *testing.T, cases map[string]response) {
+		t.Helper()
+
+		for url, want := range cases {
+			t.Run(url, func(t *testing.T) {
+				w := get(t, url)
+				if w.Code != want.status {
+					t.Errorf("got status %d, want %d", w.Code, want.status)
+				}
+
+				if ct := w.Header().Get("Content-Type"); want.contentType != "" && ct != want.contentType {
+					t.Errorf("got content type %q, want %q", ct, want.contentType)
+				}
+
+				for _, s := range want.body {
+					if !strings.Contains(w.Body.String(), s) {
+						t.Errorf("missing %q in:\n%s", s, w.Body.String())
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("rendered", func(t *testing.T) {
+		check(t, map[string]response{
+			"/": {status: http.StatusFound},
+			"/_version": {
+				status:      http.StatusOK,
+				contentType: "text/plain",
+				body:        []string{"1"},
+			},
+			"/SUMMARY.md": {
+				status:      http.StatusOK,
+				contentType: "text/html; charset=utf-8",
+				body: []string{
+					"<title>SUMMARY.md</title>",
+					`<a href="release_policy.md">Release Policy</a>`,
+					`const version = "1";`,
+				},
+			},
+			"/packages/release_a.md": {
+				status: http.StatusOK,
+				body:   []string{`<a id="a__one"></a>`, "<h3><a href=\"#a__one\">Rule one</a></h3>"},
+			},
+			"/release_collection_matrix.csv": {
+				status:      http.StatusOK,
+				contentType: "text/csv; charset=utf-8",
+				body:        []string{"a.one,Rule one,failure,x,x"},
+			},
+			"/missing.md":  {status: http.StatusNotFound},
+			"/missing.csv": {status: http.StatusNotFound},
+		})
+
+		if loc := get(t, "/").Header().Get("Location"); loc != "/SUMMARY.md" {
+			t.Errorf("got redirect to %q, want /SUMMARY.md", loc)
+		}
+	})
+
+	writeFile(t, dir, "policy/release/a/a.rego", "package a\n\ndeny if {")
+	p.render(opts, []string{dir})
+
+	t.Run("error", func(t *testing.T) {
+		check(t, map[string]response{
+			"/_version": {status: http.StatusOK, body: []string{"2"}},
+			// the pages of the last successful rendering are shown with the
+			// error
+			"/SUMMARY.md": {
+				status: http.StatusOK,
+				body: []string{
+					`<pre class="error">`,
+					"rego_parse_error",
+					`<a href="release_policy.md">Release Policy</a>`,
+				},
+			},
+			"/missing.md":  {status: http.StatusOK, body: []string{"rego_parse_error"}},
+			"/missing.csv": {status: http.StatusNotFound},
+		})
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	cases := []struct {
+		name    string
+		change  func(t *testing.T, dir string)
+		changed bool
+	}{
+		{
+			name: "modified",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, dir, "policy/release/a/a.rego", "package a\n\nimport rego.v1\n")
+			},
+			changed: true,
+		},
+		{
+			name: "added",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, dir, "policy/release/b/b.rego", "package b\n")
+			},
+			changed: true,
+		},
+		{
+			name: "rule data",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, dir, "example/data/rule_data.yml", "rule_data: {}\n")
+			},
+			changed: true,
+		},
+		{
+			name: "removed",
+			change: func(t *testing.T, dir string) {
+				if err := os.Remove(filepath.Join(dir, "policy/release/a/a.rego")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			changed: true,
+		},
+		{
+			name: "other files",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, dir, "README.md", "# Policy\n")
+			},
+		},
+		{
+			name: "hidden directories",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, dir, ".git/a.rego", "package a\n")
+			},
+		},
+		{
+			name: "node_modules",
+			change: func(t *testing.T, dir string) {
+				writeFile(t, dir, "node_modules/a/a.yaml", "a: 1\n")
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "policy/release/a/a.rego", "package a\n")
+			// the modification time is part of the fingerprint
+			past := time.Now().Add(-time.Hour)
+			if err := os.Chtimes(filepath.Join(dir, "policy/release/a/a.rego"), past, past); err != nil {
+				t.Fatal(err)
+			}
+
+			before := fingerprint([]string{dir})
+			c.change(t, dir)
+
+			if changed := fingerprint([]string{dir}) != before; changed != c.changed {
+				t.Errorf("got changed %v, want %v", changed, c.changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// timelinePage is the name, without the extension, of the page listing the
+// rules by the date they become effective
+const timelinePage = "timeline"
+
+// EffectiveRule is a rule with an effective date, i.e. a rule that does not
+// report failures before the date set in its effective_on annotation
+type EffectiveRule struct {
+	EffectiveOn time.Time `json:"effective_on"`
+	// Code is the package name and the rule's short name, e.g. tasks.required_tasks_found
+	Code  string `json:"code"`
+	Title string `json:"title"`
+	// Type is either failure or warning
+	Type string `json:"type"`
+	// Kind is the qualifier of the policy kind the rule is documented in,
+	// e.g. release
+	Kind        string   `json:"kind"`
+	KindName    string   `json:"kind_name"`
+	Collections []string `json:"collections"`
+	// Page is the path of the page documenting the rule, relative to the
+	// pages directory and without the extension
+	Page string `json:"page"`
+	// Anchor is the anchor of the rule within the page
+	Anchor string `json:"anchor"`
+}
+
+// EffectiveRules inspects the Rego directories and returns the rules with an
+// effective date of the given policy kinds, or of the discovered kinds if
+// none are given, most recent first
+func EffectiveRules(kinds []Kind, rego ...string) ([]EffectiveRule, error) {
+	m, err := load(kinds, rego)
+	if err != nil {
+		return nil, err
+	}
+
+	return effectiveRules(m.docs)
+}
+
+// Upcoming returns the rules becoming effective after now and within the
+// given number of days, soonest first
+func Upcoming(rules []EffectiveRule, now time.Time, days int) []EffectiveRule {
+	until := now.AddDate(0, 0, days)
+
+	upcoming := make([]EffectiveRule, 0, 5)
+	for _, r := range rules {
+		if r.EffectiveOn.After(now) && !r.EffectiveOn.After(until) {
+			upcoming = append(upcoming, r)
+		}
+	}
+
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].EffectiveOn.Before(upcoming[j].EffectiveOn)
+	})
+
+	return upcoming
+}
+
+// effectiveRules returns the rules with an effective date documented in the
+// given policy kinds, most recent first
+func effectiveRules(docs []doc) ([]EffectiveRule, error) {
+	rules := make([]EffectiveRule, 0, 50)
+	for _, d := range docs {
+		for _, p := range *d.Packages {
+			for _, a := range *p.Rules {
+				on := customString(a, "effective_on")
+				if on == "" {
+					continue
+				}
+
+				name := packageName(&p)
+				code := fmt.Sprintf("%s.%s", name, a.Custom["short_name"])
+
+				t, err := time.Parse(time.RFC3339, on)
+				if err != nil {
+					return nil, fmt.Errorf("rule %s has an invalid effective_on date: %w", code, err)
+				}
+
+				typ, err := warningOrFailure(a)
+				if err != nil {
+					return nil, err
+				}
+
+				anchor, err := anchor(a)
+				if err != nil {
+					return nil, err
+				}
+
+				rules = append(rules, EffectiveRule{
+					EffectiveOn: t,
+					Code:        code,
+					Title:       a.Title,
+					Type:        typ,
+					Kind:        d.Qualifier,
+					KindName:    d.Name,
+					Collections: customStrings(a, "collections"),
+					Page:        "packages/" + p.Origin + "_" + name,
+					Anchor:      anchor,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(rules, func(i, j int) bool {
+		if !rules[i].EffectiveOn.Equal(rules[j].EffectiveOn) {
+			return rules[i].EffectiveOn.After(rules[j].EffectiveOn)
+		}
+
+		return rules[i].Code < rules[j].Code
+	})
+
+	return rules, nil
+}
@@ -0,0 +1,158 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEffectiveRules(t *testing.T) {
+	dir := policyTree(t)
+	// same date as b.three, ordered by the code
+	writeFile(t, dir, "policy/release/c/c.rego", "# METADATA\n# title: C\n"+conventionsModule("c",
+		"short_name: four\neffective_on: 2025-07-01T00:00:00Z",
+		"short_name: five"))
+
+	cases := []struct {
+		name  string
+		kinds []Kind
+		want  []EffectiveRule
+	}{
+		{
+			name: "all kinds",
+			want: []EffectiveRule{
+				{
+					EffectiveOn: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+					Code:        "b.three",
+					Title:       "Rule three",
+					Type:        "failure",
+					Kind:        "task",
+					KindName:    "Task",
+					Collections: []string{},
+					Page:        "packages/task_b",
+					Anchor:      "b__three",
+				},
+				{
+					EffectiveOn: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+					Code:        "c.four",
+					Title:       "A rule",
+					Type:        "failure",
+					Kind:        "release",
+					KindName:    "Release",
+					Collections: []string{},
+					Page:        "packages/release_c",
+					Anchor:      "c__four",
+				},
+				{
+					EffectiveOn: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+					Code:        "a.one",
+					Title:       "Rule one",
+					Type:        "failure",
+					Kind:        "release",
+					KindName:    "Release",
+					Collections: []string{"minimal", "strict"},
+					Page:        "packages/release_a",
+					Anchor:      "a__one",
+				},
+			},
+		},
+		{
+			name:  "given kinds",
+			kinds: []Kind{{Name: "Tasks", Qualifier: "task"}},
+			want: []EffectiveRule{
+				{
+					EffectiveOn: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+					Code:        "b.three",
+					Title:       "Rule three",
+					Type:        "failure",
+					Kind:        "task",
+					KindName:    "Tasks",
+					Collections: []string{},
+					Page:        "packages/task_b",
+					Anchor:      "b__three",
+				},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := EffectiveRules(c.kinds, dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got rules %+v, want %+v", got, c.want)
+			}
+		})
+	}
+
+	t.Run("invalid date", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, dir, "policy/release/c/c.rego", "# METADATA\n# title: C\n"+conventionsModule("c",
+			"short_name: four\neffective_on: 2025-07-01"))
+
+		if _, err := EffectiveRules(nil, dir); err == nil || !strings.Contains(err.Error(), "rule c.four has an invalid effective_on date") {
+			t.Errorf("expected an invalid date error, got %v", err)
+		}
+	})
+}
+
+func TestUpcoming(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	rule := func(code string, days int) EffectiveRule {
+		return EffectiveRule{Code: code, EffectiveOn: now.AddDate(0, 0, days)}
+	}
+
+	// most recent first, as returned by EffectiveRules
+	rules := []EffectiveRule{
+		rule("a.later", 31),
+		rule("a.last_day", 30),
+		rule("a.soon", 10),
+		rule("b.soon", 10),
+		rule("a.tomorrow", 1),
+		rule("a.now", 0),
+		rule("a.past", -1),
+	}
+
+	cases := []struct {
+		name string
+		days int
+		want []string
+	}{
+		{name: "within 30 days", days: 30, want: []string{"a.tomorrow", "a.soon", "b.soon", "a.last_day"}},
+		{name: "within a day", days: 1, want: []string{"a.tomorrow"}},
+		{name: "none", days: 0, want: []string{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := []string{}
+			for _, r := range Upcoming(rules, now, c.days) {
+				got = append(got, r.Code)
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got rules %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+// ValidationError describes a problem with the annotations of a single rule
+// that prevents it from being rendered
+type ValidationError struct {
+	// Rule is the path of the annotated rule, e.g. data.policy.release.tasks.deny
+	Rule string
+	// Title is the title of the rule, if it has one
+	Title string
+	// Location is where the METADATA block of the rule is
+	Location *ast.Location
+	// Problem is a human readable description of what is wrong with the rule
+	Problem string
+}
+
+func (e ValidationError) Error() string {
+	rule := e.Rule
+	if e.Title != "" {
+		rule = fmt.Sprintf("%s (%q)", e.Rule, e.Title)
+	}
+
+	if e.Location == nil {
+		return fmt.Sprintf("%s: %s", rule, e.Problem)
+	}
+
+	return fmt.Sprintf("%s:%d: %s: %s", e.Location.File, e.Location.Row, rule, e.Problem)
+}
+
+// ValidationErrors holds all the problems found when validating annotations
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	if len(e) == 1 {
+		return fmt.Sprintf("1 invalid rule found: %v", e[0])
+	}
+
+	s := make([]string, 0, len(e))
+	for _, err := range e {
+		s = append(s, err.Error())
+	}
+
+	return fmt.Sprintf("%d invalid rules found:\n%s", len(e), strings.Join(s, "\n"))
+}
+
+// validate checks that all rules that would be rendered by any of the given
+// docs, or that are listed in a rule collection, have the annotations the
+// templates rely on. All problems are reported at once, nil is returned if
+// there are none.
+func validate(ds []doc, a []ast.FlatAnnotationsRefSet) error {
+	var problems ValidationErrors
+	for _, set := range a {
+		for _, ref := range set {
+			if ref.Annotations.Scope != "rule" {
+				continue
+			}
+
+			_, inCollection := ref.Annotations.Custom["collections"]
+			if !inCollection && !renderedByAny(ds, ref) {
+				continue
+			}
+
+			for _, problem := range ruleProblems(ref.Annotations) {
+				problems = append(problems, ValidationError{
+					Rule:     ref.Path.String(),
+					Title:    ref.Annotations.Title,
+					Location: ref.Annotations.Location,
+					Problem:  problem,
+				})
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
+
+func renderedByAny(ds []doc, ref *ast.AnnotationsRef) bool {
+	for _, d := range ds {
+		if d.owns(ref) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ruleProblems returns the reasons why the rule annotations can't be
+// rendered, empty if they can
+func ruleProblems(a *ast.Annotations) []string {
+	problems := make([]string, 0, 2)
+
+	switch sn := a.Custom["short_name"].(type) {
+	case nil:
+		problems = append(problems, "missing the custom.short_name annotation")
+	case string:
+		if sn == "" {
+			problems = append(problems, "the custom.short_name annotation is empty")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("the custom.short_name annotation must be a string, found %T", sn))
+	}
+
+	if _, err := ruleType(a); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	path := a.GetTargetPath()
+	if len(path) < 2 {
+		problems = append(problems, fmt.Sprintf("the rule path %q is too short to determine the package", path))
+	} else if _, err := strconv.Unquote(path[len(path)-2].String()); err != nil {
+		problems = append(problems, fmt.Sprintf("unable to determine the package name from the rule path %q", path))
+	}
+
+	return problems
+}
@@ -0,0 +1,161 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package asciidoc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/open-policy-agent/opa/ast"
+)
+
+func TestValidate(t *testing.T) {
+	release := []Kind{{Name: "Release", Qualifier: "release"}}
+
+	cases := []struct {
+		name  string
+		files map[string]string
+		// want holds the file, row, rule and problem of each error
+		want []string
+	}{
+		{
+			name: "valid rules",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a", "short_name: one", "short_name: two"),
+			},
+		},
+		{
+			name: "short_name",
+			files: map[string]string{
+				"policy/release/a/a.rego": conventionsModule("a", "failure_msg: failed", `short_name: ""`, "short_name: 1"),
+			},
+			want: []string{
+				"policy/release/a/a.rego:5: data.a.deny missing the custom.short_name annotation",
+				"policy/release/a/a.rego:14: data.a.deny the custom.short_name annotation is empty",
+				"policy/release/a/a.rego:23: data.a.deny the custom.short_name annotation must be a string, found int",
+			},
+		},
+		{
+			name: "rule type",
+			files: map[string]string{
+				"policy/release/a/a.rego": "package a\n\nimport rego.v1\n\n# METADATA\n# title: A rule\n# custom:\n#   short_name: one\nallow := true\n",
+			},
+			want: []string{
+				`policy/release/a/a.rego:5: data.a.allow the rule path "data.a.allow" does not end in .deny or .warn`,
+			},
+		},
+		{
+			name: "all problems of a rule",
+			files: map[string]string{
+				"policy/release/a/a.rego": "package a\n\nimport rego.v1\n\n# METADATA\n# title: A rule\nallow := true\n",
+			},
+			want: []string{
+				"policy/release/a/a.rego:5: data.a.allow missing the custom.short_name annotation",
+				`policy/release/a/a.rego:5: data.a.allow the rule path "data.a.allow" does not end in .deny or .warn`,
+			},
+		},
+		{
+			name: "rules of undocumented kinds",
+			files: map[string]string{
+				"policy/task/a/a.rego": conventionsModule("a", "failure_msg: failed"),
+				"policy/lib/a/a.rego":  conventionsModule("lib.a", "failure_msg: failed"),
+			},
+		},
+		{
+			name: "rules in collections",
+			files: map[string]string{
+				"policy/task/a/a.rego": conventionsModule("a", "failure_msg: failed\ncollections:\n- minimal"),
+			},
+			want: []string{
+				"policy/task/a/a.rego:5: data.a.deny missing the custom.short_name annotation",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range c.files {
+				writeFile(t, dir, name, content)
+			}
+
+			_, err := load(release, []string{dir})
+			if len(c.want) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("expected ValidationErrors, got %v", err)
+			}
+
+			got := make([]string, 0, len(errs))
+			for _, e := range errs {
+				got = append(got, fmt.Sprintf("%s:%d: %s %s", e.Location.File, e.Location.Row, e.Rule, e.Problem))
+			}
+
+			if strings.Join(got, "\n") != strings.Join(c.want, "\n") {
+				t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	located := ValidationError{
+		Rule:     "data.a.deny",
+		Title:    "A rule",
+		Location: &ast.Location{File: "a.rego", Row: 3},
+		Problem:  "missing the custom.short_name annotation",
+	}
+	untitled := ValidationError{
+		Rule:    "data.b.warn",
+		Problem: "the custom.short_name annotation is empty",
+	}
+
+	cases := []struct {
+		name string
+		errs ValidationErrors
+		want string
+	}{
+		{
+			name: "single",
+			errs: ValidationErrors{located},
+			want: `1 invalid rule found: a.rego:3: data.a.deny ("A rule"): missing the custom.short_name annotation`,
+		},
+		{
+			name: "multiple",
+			errs: ValidationErrors{located, untitled},
+			want: "2 invalid rules found:\n" +
+				`a.rego:3: data.a.deny ("A rule"): missing the custom.short_name annotation` + "\n" +
+				"data.b.warn: the custom.short_name annotation is empty",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.errs.Error(); got != c.want {
+				t.Errorf("got %q, want %q", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command changelog reports the differences in policy rules between two
+// source trees or git refs, e.g. to document what changed between two
+// releases of the policy bundles.
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/conforma/policy/docs/asciidoc"
+)
+
+var from = flag.String("from", "", "Source tree directory or git ref of the older policy rules")
+
+var to = flag.String("to", "", "Source tree directory or git ref of the newer policy rules")
+
+var jsonOut = flag.String("json", "", "Location of the JSON changelog, written to stdout if neither -json nor -page are provided")
+
+var page = flag.String("page", "", "Location of the \"What's changed\" page")
+
+var format = flag.String("format", "asciidoc", "Format of the \"What's changed\" page, one of: "+strings.Join(asciidoc.Formats(), ", "))
+
+func main() {
+	flag.Parse()
+
+	if *from == "" || *to == "" {
+		fmt.Fprintf(os.Stderr, "-from and -to flags are required\n")
+		os.Exit(1)
+	}
+
+	var err error
+	defer func() {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	var old, new asciidoc.Catalog
+	if old, err = load(*from); err != nil {
+		return
+	}
+
+	if new, err = load(*to); err != nil {
+		return
+	}
+
+	changes := asciidoc.DiffCatalogs(*from, old, *to, new)
+
+	if *jsonOut == "" && *page == "" {
+		err = asciidoc.WriteChangelog(os.Stdout, changes, "json")
+		return
+	}
+
+	if *jsonOut != "" {
+		if err = write(*jsonOut, changes, "json"); err != nil {
+			return
+		}
+	}
+
+	if *page != "" {
+		if err = write(*page, changes, *format); err != nil {
+			return
+		}
+	}
+}
+
+// load returns the catalog of rules from the source tree, if the source is
+// not a directory it is taken to be a git ref and its content is extracted
+// to a temporary directory first
+func load(source string) (asciidoc.Catalog, error) {
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		return asciidoc.LoadCatalog(nil, source)
+	}
+
+	dir, err := os.MkdirTemp("", "changelog-")
+	if err != nil {
+		return asciidoc.Catalog{}, fmt.Errorf("creating temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := extract(source, dir); err != nil {
+		return asciidoc.Catalog{}, err
+	}
+
+	return asciidoc.LoadCatalog(nil, dir)
+}
+
+// extract writes the Rego files and the example rule data from the git ref
+// into the directory
+func extract(ref, dir string) error {
+	// git archive run from a subdirectory only includes that subdirectory
+	top, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return fmt.Errorf("finding the git repository: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", "archive", "--format=tar", ref)
+	cmd.Dir = strings.TrimSpace(string(top))
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("reading git ref %q: %w\n%s", ref, err, stderr.String())
+	}
+
+	archive := tar.NewReader(&stdout)
+	for {
+		h, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading archive of git ref %q: %w", ref, err)
+		}
+
+		if h.Typeflag != tar.TypeReg || !wanted(h.Name) {
+			continue
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(h.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("unexpected path %q in archive of git ref %q", h.Name, ref)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("creating directory for %q: %w", path, err)
+		}
+
+		f, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("creating file %q: %w", path, err)
+		}
+
+		_, err = io.Copy(f, archive)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("writing file %q: %w", path, err)
+		}
+	}
+}
+
+// wanted returns true for the files needed to build the catalog
+func wanted(name string) bool {
+	return (strings.HasPrefix(name, "policy/") && strings.HasSuffix(name, ".rego")) ||
+		name == "example/data/rule_data.yml"
+}
+
+func write(path string, changes asciidoc.Changelog, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating file %q: %w", path, err)
+	}
+	defer f.Close()
+
+	return asciidoc.WriteChangelog(f, changes, format)
+}
@@ -0,0 +1,196 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command conventions checks the annotations of the policy rules against the
+// policy authoring conventions, exiting with a non-zero status if there are
+// any violations.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/conforma/policy/docs/asciidoc"
+	"github.com/conforma/policy/docs/internal/flags"
+)
+
+var format = flag.String("format", "text", "Format of the report, one of: text, json, sarif")
+
+var output = flag.String("output", "", "Location of the report, written to stdout if not provided")
+
+var rego flags.Strings
+
+func main() {
+	flag.Var(&rego, "rego", "Location of the Rego files")
+	flag.Parse()
+
+	if len(rego) == 0 {
+		fmt.Fprintf(os.Stderr, "-rego flag is required\n")
+		os.Exit(1)
+	}
+
+	var violations []asciidoc.Violation
+	var err error
+	defer func() {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		if len(violations) > 0 {
+			os.Exit(1)
+		}
+	}()
+
+	if violations, err = asciidoc.CheckConventions(rego...); err != nil {
+		return
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		if out, err = os.Create(*output); err != nil {
+			return
+		}
+		defer out.Close()
+	}
+
+	switch *format {
+	case "text":
+		err = writeText(out, violations)
+	case "json":
+		err = asciidoc.WriteJSON(out, violations)
+	case "sarif":
+		err = asciidoc.WriteJSON(out, sarif(violations))
+	default:
+		err = fmt.Errorf("unsupported format %q, expecting one of: text, json, sarif", *format)
+	}
+}
+
+func writeText(w io.Writer, violations []asciidoc.Violation) error {
+	for _, v := range violations {
+		if _, err := fmt.Fprintln(w, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Subset of the SARIF 2.1.0 format, see
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+
+	sarifRegion struct {
+		StartLine int `json:"startLine"`
+	}
+)
+
+func sarif(violations []asciidoc.Violation) sarifLog {
+	ids := make([]string, 0, len(asciidoc.ConventionChecks))
+	for id := range asciidoc.ConventionChecks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	rules := make([]sarifRule, 0, len(ids))
+	for _, id := range ids {
+		rules = append(rules, sarifRule{
+			ID:               id,
+			ShortDescription: sarifMessage{asciidoc.ConventionChecks[id]},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(violations))
+	for _, v := range violations {
+		l := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: v.Location.File},
+		}
+		if v.Location.Row > 0 {
+			l.Region = &sarifRegion{StartLine: v.Location.Row}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    v.Check,
+			Level:     "error",
+			Message:   sarifMessage{v.Message},
+			Locations: []sarifLocation{{PhysicalLocation: l}},
+		})
+	}
+
+	return sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "conventions",
+				InformationURI: "https://github.com/conforma/policy",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+}
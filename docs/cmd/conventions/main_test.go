@@ -0,0 +1,150 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/conforma/policy/docs/asciidoc"
+)
+
+var violations = []asciidoc.Violation{
+	{
+		Check:    asciidoc.CheckDependencyExists,
+		Message:  `Missing dependency rule "b.two"`,
+		Code:     "a.one",
+		Location: asciidoc.Source{File: "policy/release/a/a.rego", Row: 7},
+	},
+	{
+		Check:    asciidoc.CheckParse,
+		Message:  "unexpected eof token",
+		Location: asciidoc.Source{File: "policy/release/b/b.rego"},
+	},
+}
+
+// decode writes the value as JSON and decodes it again, so it can be compared
+// with the expected JSON document
+func decode(t *testing.T, v any) any {
+	t.Helper()
+
+	var b bytes.Buffer
+	if err := asciidoc.WriteJSON(&b, v); err != nil {
+		t.Fatal(err)
+	}
+
+	var got any
+	if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	return got
+}
+
+func parse(t *testing.T, s string) any {
+	t.Helper()
+
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatal(err)
+	}
+
+	return v
+}
+
+func TestJSON(t *testing.T) {
+	got := decode(t, violations)
+
+	want := parse(t, `[
+		{
+			"check": "dependency-exists",
+			"message": "Missing dependency rule \"b.two\"",
+			"code": "a.one",
+			"location": {"file": "policy/release/a/a.rego", "row": 7}
+		},
+		{
+			"check": "parse",
+			"message": "unexpected eof token",
+			"location": {"file": "policy/release/b/b.rego", "row": 0}
+		}
+	]`)
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	got := decode(t, sarif(violations)).(map[string]any)
+
+	if got["version"] != "2.1.0" || got["$schema"] != "https://json.schemastore.org/sarif-2.1.0.json" {
+		t.Errorf("unexpected SARIF version %v and schema %v", got["version"], got["$schema"])
+	}
+
+	runs := got["runs"].([]any)
+	if len(runs) != 1 {
+		t.Fatalf("got %d runs, want 1", len(runs))
+	}
+	run := runs[0].(map[string]any)
+
+	driver := run["tool"].(map[string]any)["driver"].(map[string]any)
+	if driver["name"] != "conventions" {
+		t.Errorf("got driver %v, want conventions", driver["name"])
+	}
+
+	rules := driver["rules"].([]any)
+	if len(rules) != len(asciidoc.ConventionChecks) {
+		t.Errorf("got %d rules, want one for each of the %d checks", len(rules), len(asciidoc.ConventionChecks))
+	}
+	for _, r := range rules {
+		r := r.(map[string]any)
+		id := r["id"].(string)
+		if want := asciidoc.ConventionChecks[id]; r["shortDescription"].(map[string]any)["text"] != want {
+			t.Errorf("got description %v of rule %s, want %q", r["shortDescription"], id, want)
+		}
+	}
+
+	want := parse(t, `[
+		{
+			"ruleId": "dependency-exists",
+			"level": "error",
+			"message": {"text": "Missing dependency rule \"b.two\""},
+			"locations": [{
+				"physicalLocation": {
+					"artifactLocation": {"uri": "policy/release/a/a.rego"},
+					"region": {"startLine": 7}
+				}
+			}]
+		},
+		{
+			"ruleId": "parse",
+			"level": "error",
+			"message": {"text": "unexpected eof token"},
+			"locations": [{
+				"physicalLocation": {
+					"artifactLocation": {"uri": "policy/release/b/b.rego"}
+				}
+			}]
+		}
+	]`)
+
+	if !reflect.DeepEqual(run["results"], want) {
+		t.Errorf("got results %v, want %v", run["results"], want)
+	}
+}
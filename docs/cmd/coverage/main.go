@@ -0,0 +1,192 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command coverage maps the OPA test coverage report to the documented
+// packages and rules, and exits with a non-zero status if the coverage of any
+// package drops below its own threshold. The coverage of a package counts all
+// lines of its files, helpers included, the thresholds apply to that figure.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/conforma/policy/docs/asciidoc"
+	"github.com/conforma/policy/docs/internal/flags"
+)
+
+var report = flag.String("report", "", "OPA test coverage report, from opa test --coverage --format json")
+
+var thresholds = flag.String("thresholds", "", "YAML file with the minimum coverage, in percent, of each package, e.g. release.tasks: 100")
+
+var update = flag.Bool("update", false, "Write the current coverage of each package to the thresholds file instead of checking it")
+
+var format = flag.String("format", "text", "Format of the coverage, one of: text, json")
+
+var rules = flag.Bool("rules", false, "Include the coverage of all rules in the text format, by default only rules not fully covered are included")
+
+var rego flags.Strings
+
+const thresholdsHeader = `# Copyright The Conforma Contributors
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+#
+# SPDX-License-Identifier: Apache-2.0
+
+# Minimum test coverage, in percent, of each package. Update by running:
+#   make coverage-report COVERAGE_UPDATE=1
+`
+
+func main() {
+	flag.Var(&rego, "rego", "Location of the Rego files")
+	flag.Parse()
+
+	if *report == "" || len(rego) == 0 {
+		fmt.Fprintf(os.Stderr, "-report and -rego flags are required\n")
+		os.Exit(1)
+	}
+
+	var err error
+	defer func() {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	var coverage asciidoc.Coverage
+	if coverage, err = asciidoc.LoadCoverage(*report, nil, rego...); err != nil {
+		return
+	}
+
+	if *update {
+		if *thresholds == "" {
+			err = errors.New("-thresholds flag is required with -update")
+			return
+		}
+
+		err = writeThresholds(*thresholds, coverage)
+		return
+	}
+
+	var min map[string]float64
+	if min, err = readThresholds(*thresholds); err != nil {
+		return
+	}
+
+	switch *format {
+	case "text":
+		err = writeText(os.Stdout, coverage, min)
+	case "json":
+		err = asciidoc.WriteJSON(os.Stdout, coverage)
+	default:
+		err = fmt.Errorf("unsupported format %q, expecting one of: text, json", *format)
+	}
+	if err != nil {
+		return
+	}
+
+	below := 0
+	for _, p := range coverage.Packages {
+		if t, ok := min[p.Name]; ok && p.Coverage < t {
+			fmt.Fprintf(os.Stderr, "%s: coverage %.1f%% is below the threshold of %.1f%%\n", p.Name, p.Coverage, t)
+			below++
+		}
+	}
+
+	if below > 0 {
+		os.Exit(1)
+	}
+}
+
+// readThresholds reads the minimum coverage of each package, packages
+// without a threshold, or all packages if no thresholds file is given, are
+// never below their threshold
+func readThresholds(file string) (map[string]float64, error) {
+	min := map[string]float64{}
+	if file == "" {
+		return min, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("the thresholds file %q does not exist, create it with -update", file)
+		}
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(data, &min); err != nil {
+		return nil, fmt.Errorf("parsing the thresholds %q: %w", file, err)
+	}
+
+	return min, nil
+}
+
+// writeThresholds records the current coverage of each package, rounded
+// down, as its threshold
+func writeThresholds(file string, coverage asciidoc.Coverage) error {
+	min := make(map[string]float64, len(coverage.Packages))
+	for _, p := range coverage.Packages {
+		min[p.Name] = math.Floor(p.Coverage*10) / 10
+	}
+
+	data, err := yaml.Marshal(min)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(file, append([]byte(thresholdsHeader), data...), 0644)
+}
+
+func writeText(w io.Writer, coverage asciidoc.Coverage, min map[string]float64) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tCOVERAGE\tLINES\tTHRESHOLD")
+	for _, p := range coverage.Packages {
+		threshold := "-"
+		if t, ok := min[p.Name]; ok {
+			threshold = fmt.Sprintf("%.1f%%", t)
+		}
+		fmt.Fprintf(tw, "%s\t%.1f%%\t%d/%d\t%s\n", p.Name, p.Coverage, p.Covered, p.Covered+p.NotCovered, threshold)
+
+		for _, r := range p.Rules {
+			if !*rules && r.Coverage == 100 {
+				continue
+			}
+			fmt.Fprintf(tw, "  %s\t%.1f%%\t%d/%d\t\n", r.Code, r.Coverage, r.Covered, r.Covered+r.NotCovered)
+		}
+	}
+
+	return tw.Flush()
+}
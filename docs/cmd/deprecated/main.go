@@ -0,0 +1,196 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command deprecated lists the deprecated rules, i.e. rules with the
+// custom.deprecated annotation, that are still in use: listed in collections
+// or referenced by code in the given files, e.g. the example data or the
+// acceptance test features, and exits with a non-zero status if there are any.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/conforma/policy/docs/asciidoc"
+	"github.com/conforma/policy/docs/internal/flags"
+)
+
+var format = flag.String("format", "text", "Format of the report, one of: text, json")
+
+var rego flags.Strings
+
+var search flags.Strings
+
+// usage is a deprecated rule and the places it is still used in
+type usage struct {
+	asciidoc.CatalogRule
+	References []reference `json:"references"`
+}
+
+// reference is a line referencing a deprecated rule by its code, e.g. in the
+// include or exclude list of a policy config
+type reference struct {
+	File string `json:"file"`
+	Row  int    `json:"row"`
+	Text string `json:"text"`
+}
+
+// InUse returns true if the rule is listed in collections or referenced
+func (u usage) InUse() bool {
+	return len(u.Collections) > 0 || len(u.References) > 0
+}
+
+func main() {
+	flag.Var(&rego, "rego", "Location of the Rego files")
+	flag.Var(&search, "search", "File or directory to search for references to the deprecated rules, can be repeated")
+	flag.Parse()
+
+	if len(rego) == 0 {
+		fmt.Fprintf(os.Stderr, "-rego flag is required\n")
+		os.Exit(1)
+	}
+
+	var err error
+	defer func() {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	var catalog asciidoc.Catalog
+	if catalog, err = asciidoc.LoadCatalog(nil, rego...); err != nil {
+		return
+	}
+
+	usages := make([]usage, 0, 5)
+	for _, r := range catalog.Rules {
+		if r.Deprecated != nil {
+			usages = append(usages, usage{CatalogRule: r, References: []reference{}})
+		}
+	}
+
+	if err = findReferences(usages, search); err != nil {
+		return
+	}
+
+	switch *format {
+	case "text":
+		err = writeText(os.Stdout, usages)
+	case "json":
+		err = asciidoc.WriteJSON(os.Stdout, usages)
+	default:
+		err = fmt.Errorf("unsupported format %q, expecting one of: text, json", *format)
+	}
+	if err != nil {
+		return
+	}
+
+	for _, u := range usages {
+		if u.InUse() {
+			fmt.Fprintf(os.Stderr, "deprecated rules are still in use\n")
+			os.Exit(1)
+		}
+	}
+}
+
+// findReferences records the lines of the files, within the given files and
+// directories, containing the code of each of the deprecated rules
+func findReferences(usages []usage, paths []string) error {
+	if len(usages) == 0 {
+		return nil
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(usages))
+	for _, u := range usages {
+		// the code must not be part of a longer code, it can be followed by a
+		// term, e.g. tasks.required_tasks_found:buildah
+		patterns = append(patterns, regexp.MustCompile(`(^|[^\w.])`+regexp.QuoteMeta(u.Code)+`($|[^\w.])`))
+	}
+
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(file string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			f, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			s := bufio.NewScanner(f)
+			for row := 1; s.Scan(); row++ {
+				line := s.Text()
+				for i, re := range patterns {
+					if re.MatchString(line) {
+						usages[i].References = append(usages[i].References, reference{
+							File: file,
+							Row:  row,
+							Text: strings.TrimSpace(line),
+						})
+					}
+				}
+			}
+
+			return s.Err()
+		})
+		if err != nil {
+			return fmt.Errorf("searching for references in %q: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+func writeText(w io.Writer, usages []usage) error {
+	var b strings.Builder
+	for i, u := range usages {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		fmt.Fprintf(&b, "%s (%s) deprecated since %s", u.Code, u.Origin, u.Deprecated.Since)
+		if u.ReplacedBy != "" {
+			fmt.Fprintf(&b, ", replaced by %s", u.ReplacedBy)
+		}
+		fmt.Fprintf(&b, "\n  %s\n  Source: %s:%d\n", u.Deprecated.Reason, u.Source.File, u.Source.Row)
+
+		if len(u.Collections) > 0 {
+			fmt.Fprintf(&b, "  Listed in collections: %s\n", strings.Join(u.Collections, ", "))
+		}
+
+		for _, r := range u.References {
+			fmt.Fprintf(&b, "  Referenced at %s:%d: %s\n", r.File, r.Row, r.Text)
+		}
+
+		if !u.InUse() {
+			b.WriteString("  Not in use, ready to be removed\n")
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
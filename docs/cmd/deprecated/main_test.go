@@ -0,0 +1,147 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conforma/policy/docs/asciidoc"
+)
+
+func deprecated(code string, collections ...string) usage {
+	return usage{
+		CatalogRule: asciidoc.CatalogRule{
+			Code:        code,
+			Origin:      "release",
+			Collections: collections,
+			Deprecated:  &asciidoc.Deprecation{Since: "2025-06-01T00:00:00Z", Reason: "Replaced."},
+			Source:      asciidoc.Source{File: "policy/release/a/a.rego", Row: 8},
+		},
+		References: []reference{},
+	}
+}
+
+func TestFindReferences(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"example/data/config.yaml": "include:\n  - a.old\n  - a.old_name\n  - xa.old\n  - a.old.more\n",
+		"features/a.feature":       "    exclude: [\"a.old:buildah\", \"b.gone\"]\n",
+		"other/b.txt":              "a.old\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name  string
+		paths []string
+		want  []string
+	}{
+		{
+			name:  "directories",
+			paths: []string{filepath.Join(dir, "example"), filepath.Join(dir, "features")},
+			want: []string{
+				"a.old example/data/config.yaml:2: - a.old",
+				`a.old features/a.feature:1: exclude: ["a.old:buildah", "b.gone"]`,
+				`b.gone features/a.feature:1: exclude: ["a.old:buildah", "b.gone"]`,
+			},
+		},
+		{
+			name:  "file",
+			paths: []string{filepath.Join(dir, "other", "b.txt")},
+			want:  []string{"a.old other/b.txt:1: a.old"},
+		},
+		{
+			name: "nothing to search",
+			want: []string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			usages := []usage{deprecated("a.old"), deprecated("b.gone")}
+			if err := findReferences(usages, c.paths); err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			for _, u := range usages {
+				for _, r := range u.References {
+					rel, err := filepath.Rel(dir, r.File)
+					if err != nil {
+						t.Fatal(err)
+					}
+					got = append(got, fmt.Sprintf("%s %s:%d: %s", u.Code, filepath.ToSlash(rel), r.Row, r.Text))
+				}
+			}
+
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("got references:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(c.want, "\n"))
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		err := findReferences([]usage{deprecated("a.old")}, []string{filepath.Join(dir, "missing")})
+		if err == nil || !strings.Contains(err.Error(), "searching for references") {
+			t.Errorf("expected a search error, got %v", err)
+		}
+	})
+}
+
+func TestWriteText(t *testing.T) {
+	listed := deprecated("a.old", "minimal", "redhat")
+	listed.ReplacedBy = "a.new"
+	listed.References = []reference{{File: "example/data/config.yaml", Row: 2, Text: "- a.old"}}
+
+	unused := deprecated("b.gone")
+
+	var got strings.Builder
+	if err := writeText(&got, []usage{listed, unused}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `a.old (release) deprecated since 2025-06-01T00:00:00Z, replaced by a.new
+  Replaced.
+  Source: policy/release/a/a.rego:8
+  Listed in collections: minimal, redhat
+  Referenced at example/data/config.yaml:2: - a.old
+
+b.gone (release) deprecated since 2025-06-01T00:00:00Z
+  Replaced.
+  Source: policy/release/a/a.rego:8
+  Not in use, ready to be removed
+`
+	if got.String() != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got.String(), want)
+	}
+
+	if !listed.InUse() || unused.InUse() {
+		t.Errorf("got in use %v and %v, want true and false", listed.InUse(), unused.InUse())
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command effective lists the rules becoming effective, i.e. the rules with an
+// effective_on date, within the given number of days so that the teams
+// affected can be given notice.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/conforma/policy/docs/asciidoc"
+	"github.com/conforma/policy/docs/internal/flags"
+)
+
+var days = flag.Int("days", 30, "Number of days to list the rules becoming effective in")
+
+var from = flag.String("from", "", "Date, in the YYYY-MM-DD format, the days are counted from, today if not provided")
+
+var format = flag.String("format", "text", "Format of the list, one of: text, json")
+
+var rego flags.Strings
+
+func main() {
+	flag.Var(&rego, "rego", "Location of the Rego files")
+	flag.Parse()
+
+	if len(rego) == 0 {
+		fmt.Fprintf(os.Stderr, "-rego flag is required\n")
+		os.Exit(1)
+	}
+
+	var err error
+	defer func() {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	var rules []asciidoc.EffectiveRule
+	if rules, err = asciidoc.EffectiveRules(nil, rego...); err != nil {
+		return
+	}
+
+	now := time.Now()
+	if *from != "" {
+		if now, err = time.Parse(time.DateOnly, *from); err != nil {
+			return
+		}
+	}
+
+	upcoming := asciidoc.Upcoming(rules, now, *days)
+
+	switch *format {
+	case "text":
+		err = writeText(os.Stdout, upcoming)
+	case "json":
+		err = asciidoc.WriteJSON(os.Stdout, upcoming)
+	default:
+		err = fmt.Errorf("unsupported format %q, expecting one of: text, json", *format)
+	}
+}
+
+func writeText(w io.Writer, rules []asciidoc.EffectiveRule) error {
+	if len(rules) == 0 {
+		_, err := fmt.Fprintf(w, "No rules become effective within %d days\n", *days)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "EFFECTIVE ON\tTYPE\tPOLICY\tCODE\tTITLE\tCOLLECTIONS")
+	for _, r := range rules {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", r.EffectiveOn.Format(time.DateOnly), r.Type, r.Kind, r.Code, r.Title, strings.Join(r.Collections, ", "))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,75 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/conforma/policy/docs/asciidoc"
+)
+
+func TestWriteText(t *testing.T) {
+	cases := []struct {
+		name  string
+		rules []asciidoc.EffectiveRule
+		want  string
+	}{
+		{
+			name: "none",
+			want: "No rules become effective within 30 days\n",
+		},
+		{
+			name: "rules",
+			rules: []asciidoc.EffectiveRule{
+				{
+					EffectiveOn: time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC),
+					Code:        "tasks.required_tasks_found",
+					Title:       "Required tasks found",
+					Type:        "failure",
+					Kind:        "release",
+					Collections: []string{"minimal", "redhat"},
+				},
+				{
+					EffectiveOn: time.Date(2025, 6, 20, 0, 0, 0, 0, time.UTC),
+					Code:        "a.b",
+					Title:       "A rule",
+					Type:        "warning",
+					Kind:        "task",
+				},
+			},
+			want: `EFFECTIVE ON  TYPE     POLICY   CODE                        TITLE                 COLLECTIONS
+2025-06-10    failure  release  tasks.required_tasks_found  Required tasks found  minimal, redhat
+2025-06-20    warning  task     a.b                         A rule                
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got strings.Builder
+			if err := writeText(&got, c.rules); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.String() != c.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got.String(), c.want)
+			}
+		})
+	}
+}
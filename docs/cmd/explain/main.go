@@ -0,0 +1,250 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Command explain prints the documentation of the rules with the given codes,
+// or of the rules reporting the violations and warnings found in an ec JSON
+// report, e.g. the output of ec validate image --output json.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/conforma/policy/docs/asciidoc"
+	"github.com/conforma/policy/docs/internal/flags"
+)
+
+var report = flag.String("report", "", "ec JSON report to explain the violations and warnings of, use - to read it from stdin")
+
+var format = flag.String("format", "text", "Format of the explanation, one of: text, json")
+
+var rego flags.Strings
+
+// The subset of the ec report holding the results, ec validate image reports
+// them for each component, ec validate input for each file
+type (
+	ecReport struct {
+		Components []ecResults `json:"components"`
+		FilePaths  []ecResults `json:"filepaths"`
+	}
+
+	ecResults struct {
+		Violations []ecResult `json:"violations"`
+		Warnings   []ecResult `json:"warnings"`
+	}
+
+	ecResult struct {
+		Message  string `json:"msg"`
+		Metadata struct {
+			Code string `json:"code"`
+		} `json:"metadata"`
+	}
+)
+
+// explanation holds the documentation of the rules with the code, more than
+// one rule is found if policy kinds use the same code
+type explanation struct {
+	Code string `json:"code"`
+	// Messages are the distinct messages reported with the code, only when
+	// explaining a report
+	Messages []string               `json:"messages,omitempty"`
+	Rules    []asciidoc.CatalogRule `json:"rules"`
+}
+
+func main() {
+	flag.Var(&rego, "rego", "Location of the Rego files")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -rego <dir> [flags] [code...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if len(rego) == 0 {
+		fmt.Fprintf(os.Stderr, "-rego flag is required\n")
+		os.Exit(1)
+	}
+
+	if *report == "" && flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "provide the rule codes to explain or the -report flag\n")
+		os.Exit(1)
+	}
+
+	var err error
+	defer func() {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	codes := flag.Args()
+	messages := map[string][]string{}
+	if *report != "" {
+		var reported []string
+		if reported, messages, err = readReport(*report); err != nil {
+			return
+		}
+		codes = append(codes, reported...)
+	}
+
+	var catalog asciidoc.Catalog
+	if catalog, err = asciidoc.LoadCatalog(nil, rego...); err != nil {
+		return
+	}
+
+	rules := map[string][]asciidoc.CatalogRule{}
+	for _, r := range catalog.Rules {
+		rules[r.Code] = append(rules[r.Code], r)
+	}
+
+	explanations := make([]explanation, 0, len(codes))
+	var unknown []string
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+
+		if _, ok := rules[code]; !ok {
+			unknown = append(unknown, code)
+			continue
+		}
+
+		explanations = append(explanations, explanation{
+			Code:     code,
+			Messages: messages[code],
+			Rules:    rules[code],
+		})
+	}
+
+	switch *format {
+	case "text":
+		err = writeText(os.Stdout, explanations)
+	case "json":
+		err = asciidoc.WriteJSON(os.Stdout, explanations)
+	default:
+		err = fmt.Errorf("unsupported format %q, expecting one of: text, json", *format)
+	}
+	if err != nil {
+		return
+	}
+
+	if len(unknown) > 0 {
+		err = fmt.Errorf("no rule found with the code: %s", strings.Join(unknown, ", "))
+	}
+}
+
+// readReport returns the sorted codes of the violations and warnings in the
+// ec report, along with the distinct messages reported for each code
+func readReport(file string) ([]string, map[string][]string, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading the report: %w", err)
+	}
+
+	var r ecReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, nil, fmt.Errorf("parsing the report: %w", err)
+	}
+
+	messages := map[string][]string{}
+	for _, rs := range append(r.Components, r.FilePaths...) {
+		for _, result := range append(rs.Violations, rs.Warnings...) {
+			code := result.Metadata.Code
+			if code == "" {
+				continue
+			}
+
+			if !slices.Contains(messages[code], result.Message) {
+				messages[code] = append(messages[code], result.Message)
+			}
+		}
+	}
+
+	if len(messages) == 0 {
+		return nil, nil, errors.New("the report holds no violations or warnings")
+	}
+
+	codes := make([]string, 0, len(messages))
+	for code := range messages {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	return codes, messages, nil
+}
+
+func writeText(w io.Writer, explanations []explanation) error {
+	var b strings.Builder
+	for i, e := range explanations {
+		for j, r := range e.Rules {
+			if i > 0 || j > 0 {
+				b.WriteString("\n")
+			}
+
+			typ := "failure"
+			if r.Type == "warn" {
+				typ = "warning"
+			}
+
+			fmt.Fprintf(&b, "%s (%s %s)\n", r.Code, r.Origin, typ)
+			field(&b, "Title", r.Title)
+			if d := r.Deprecated; d != nil {
+				field(&b, "Deprecated", fmt.Sprintf("since %s: %s", d.Since, d.Reason))
+				field(&b, "Replaced by", r.ReplacedBy)
+			}
+			field(&b, "Description", r.Description)
+			field(&b, "Solution", r.Solution)
+			field(&b, "Effective on", r.EffectiveOn)
+			field(&b, "Collections", strings.Join(r.Collections, ", "))
+			field(&b, "Rule data", strings.Join(r.RuleData, ", "))
+			field(&b, "Source", fmt.Sprintf("%s:%d", r.Source.File, r.Source.Row))
+			for _, m := range e.Messages {
+				field(&b, "Reported", m)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// field writes the value, if any, indenting the lines after the first one to
+// line up with it
+func field(b *strings.Builder, name, value string) {
+	if value == "" {
+		return
+	}
+
+	label := fmt.Sprintf("  %-13s ", name+":")
+	indent := strings.Repeat(" ", len(label))
+	fmt.Fprintf(b, "%s%s\n", label, strings.ReplaceAll(strings.TrimSpace(value), "\n", "\n"+indent))
+}
@@ -0,0 +1,203 @@
+// Copyright The Conforma Contributors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/conforma/policy/docs/asciidoc"
+)
+
+func TestReadReport(t *testing.T) {
+	cases := []struct {
+		name     string
+		report   string
+		codes    []string
+		messages map[string][]string
+		err      string
+	}{
+		{
+			name: "image report",
+			report: `{"components": [
+				{"violations": [
+					{"msg": "Task a missing", "metadata": {"code": "tasks.required_tasks_found"}},
+					{"msg": "No code"}
+				], "warnings": [
+					{"msg": "Old attestation", "metadata": {"code": "attestation_type.deprecated_policy_attestation_format"}}
+				]},
+				{"violations": [
+					{"msg": "Task a missing", "metadata": {"code": "tasks.required_tasks_found"}},
+					{"msg": "Task b missing", "metadata": {"code": "tasks.required_tasks_found"}}
+				]}
+			]}`,
+			codes: []string{"attestation_type.deprecated_policy_attestation_format", "tasks.required_tasks_found"},
+			messages: map[string][]string{
+				"attestation_type.deprecated_policy_attestation_format": {"Old attestation"},
+				"tasks.required_tasks_found":                            {"Task a missing", "Task b missing"},
+			},
+		},
+		{
+			name:     "input report",
+			report:   `{"filepaths": [{"violations": [{"msg": "Not a task", "metadata": {"code": "kind.expected_kind"}}]}]}`,
+			codes:    []string{"kind.expected_kind"},
+			messages: map[string][]string{"kind.expected_kind": {"Not a task"}},
+		},
+		{
+			name:   "no results",
+			report: `{"components": [{"successes": [{"msg": "Pass"}]}]}`,
+			err:    "the report holds no violations or warnings",
+		},
+		{
+			name:   "invalid",
+			report: `{"components": `,
+			err:    "parsing the report",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "report.json")
+			if err := os.WriteFile(file, []byte(c.report), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			codes, messages, err := readReport(file)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("expected error containing %q, got %v", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(codes, c.codes) {
+				t.Errorf("got codes %v, want %v", codes, c.codes)
+			}
+			if !reflect.DeepEqual(messages, c.messages) {
+				t.Errorf("got messages %v, want %v", messages, c.messages)
+			}
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		if _, _, err := readReport(filepath.Join(t.TempDir(), "report.json")); err == nil || !strings.Contains(err.Error(), "reading the report") {
+			t.Errorf("expected a read error, got %v", err)
+		}
+	})
+}
+
+func TestWriteText(t *testing.T) {
+	rule := asciidoc.CatalogRule{
+		Code:        "a.one",
+		Origin:      "release",
+		Title:       "Rule one",
+		Description: "The first rule.\nIt has two lines.",
+		Solution:    "Fix it.",
+		Type:        "deny",
+		EffectiveOn: "2025-05-01T00:00:00Z",
+		Collections: []string{"minimal", "strict"},
+		RuleData:    []string{"allowed"},
+		Source:      asciidoc.Source{File: "policy/release/a/a.rego", Row: 8},
+	}
+
+	cases := []struct {
+		name         string
+		explanations []explanation
+		want         string
+	}{
+		{
+			name:         "rule",
+			explanations: []explanation{{Code: "a.one", Rules: []asciidoc.CatalogRule{rule}}},
+			want: `a.one (release failure)
+  Title:        Rule one
+  Description:  The first rule.
+                It has two lines.
+  Solution:     Fix it.
+  Effective on: 2025-05-01T00:00:00Z
+  Collections:  minimal, strict
+  Rule data:    allowed
+  Source:       policy/release/a/a.rego:8
+`,
+		},
+		{
+			name: "reported deprecated warning",
+			explanations: []explanation{{
+				Code:     "a.two",
+				Messages: []string{"Two failed", "Two failed again"},
+				Rules: []asciidoc.CatalogRule{{
+					Code:        "a.two",
+					Origin:      "task",
+					Title:       "Rule two",
+					Type:        "warn",
+					Deprecated:  &asciidoc.Deprecation{Since: "2025-06-01T00:00:00Z", Reason: "Replaced."},
+					ReplacedBy:  "a.one",
+					Description: "The second rule.",
+					Source:      asciidoc.Source{File: "policy/task/a/a.rego", Row: 3},
+				}},
+			}},
+			want: `a.two (task warning)
+  Title:        Rule two
+  Deprecated:   since 2025-06-01T00:00:00Z: Replaced.
+  Replaced by:  a.one
+  Description:  The second rule.
+  Source:       policy/task/a/a.rego:3
+  Reported:     Two failed
+  Reported:     Two failed again
+`,
+		},
+		{
+			name: "many rules",
+			explanations: []explanation{
+				{Code: "a.one", Rules: []asciidoc.CatalogRule{
+					{Code: "a.one", Origin: "release", Type: "deny", Source: asciidoc.Source{File: "r.rego", Row: 1}},
+					{Code: "a.one", Origin: "task", Type: "deny", Source: asciidoc.Source{File: "t.rego", Row: 1}},
+				}},
+				{Code: "b.one", Rules: []asciidoc.CatalogRule{
+					{Code: "b.one", Origin: "release", Type: "warn", Source: asciidoc.Source{File: "b.rego", Row: 2}},
+				}},
+			},
+			want: `a.one (release failure)
+  Source:       r.rego:1
+
+a.one (task failure)
+  Source:       t.rego:1
+
+b.one (release warning)
+  Source:       b.rego:2
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got strings.Builder
+			if err := writeText(&got, c.explanations); err != nil {
+				t.Fatal(err)
+			}
+
+			if got.String() != c.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got.String(), c.want)
+			}
+		})
+	}
+}
@@ -17,51 +17,139 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
 
+	"github.com/open-policy-agent/opa/ast"
+
 	"github.com/conforma/policy/docs/asciidoc"
+	"github.com/conforma/policy/docs/internal/flags"
 )
 
-var adoc = flag.String("adoc", "", "Location of the generated Asciidoc files")
+var adoc = flag.String("adoc", "", "Location of the generated documentation files, the Antora module directory when generating Asciidoc")
 
-var rego stringAry
+var format = flag.String("format", "asciidoc", "Format of the generated documentation, one of: "+strings.Join(asciidoc.Formats(), ", "))
 
-type stringAry []string
+var config = flag.String("config", "", "Location of a YAML or JSON manifest listing the policy kinds to document, by default the kinds are discovered from the directories under policy/")
 
-func (s *stringAry) String() string {
-	return strings.Join(*s, ",")
-}
+var catalog = flag.Bool("catalog", true, "Write the machine readable rule catalog, rules.json, alongside the documentation")
 
-func (s *stringAry) Set(v string) error {
-	*s = append(*s, v)
-	return nil
-}
+var graph = flag.String("graph", "", "Also write the rule dependency graph in the given format, one of: "+strings.Join(asciidoc.GraphFormats(), ", "))
+
+var check = flag.Bool("check", false, "Only check that the documentation is up to date, prints the differences and exits with a non-zero status if it is not")
+
+var templates = flag.String("templates", "", "Directory with templates overriding the embedded templates of the same name, e.g. package.template")
+
+var coverage = flag.String("coverage", "", "OPA test coverage report, from opa test --coverage --format json, to show the test coverage of the packages and rules")
+
+var warnings = flag.Bool("warnings", false, "Print the problems found that do not prevent generating the documentation, e.g. library functions without a description")
+
+var serve = flag.String("serve", "", "Serve a live preview of the documentation as HTML at the given address, e.g. localhost:8000, instead of generating it, the pages are rendered again when the Rego files or the Markdown templates change")
+
+var sourceURL = flag.String("source-url", "", "Base of the links to the source of the rules read from the unnamed Rego directories without a URL, e.g. https://github.com/conforma/policy/blob/v1.0.0, by default the Asciidoc pages link to the revision the Antora site is built from and the Markdown pages are not linked to the source")
+
+var rego flags.Strings
 
 func main() {
-	flag.Var(&rego, "rego", "Location of the Rego files")
+	flag.Var(&rego, "rego", "Location of the Rego files, as [name=]path[,kind=KIND][,url=URL], the files of a location with a kind outside of its policy directory belong to that policy kind, the source of the files is linked to at the URL, the files of a named location without a URL are not linked to, can be repeated")
 	flag.Parse()
 
-	if *adoc == "" || len(rego) == 0 {
-		fmt.Fprintf(os.Stderr, "-adoc and -rego flags are required\n")
+	if (*adoc == "" && *serve == "") || len(rego) == 0 {
+		fmt.Fprintf(os.Stderr, "-adoc, or -serve, and -rego flags are required\n")
 		os.Exit(1)
 	}
 
 	var err error
 	defer func() {
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "%v\n", err)
+			report(err)
 			os.Exit(1)
 		}
 	}()
 
+	var kinds []asciidoc.Kind
+	if *config != "" {
+		if kinds, err = asciidoc.LoadKinds(*config); err != nil {
+			return
+		}
+	}
+
+	opts := asciidoc.Options{
+		Format:    *format,
+		Kinds:     kinds,
+		Graph:     *graph,
+		Catalog:   *catalog,
+		Templates: *templates,
+		Coverage:  *coverage,
+		SourceURL: *sourceURL,
+	}
+
+	if *warnings {
+		opts.Warnings = os.Stderr
+	}
+
+	if *serve != "" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		fmt.Fprintf(os.Stderr, "Serving the documentation at http://%s\n", *serve)
+		err = asciidoc.Serve(ctx, *serve, opts, rego...)
+
+		return
+	}
+
+	if *check {
+		var diff string
+		if diff, err = asciidoc.Check(*adoc, opts, rego...); err != nil {
+			return
+		}
+
+		if diff != "" {
+			fmt.Print(diff)
+			fmt.Fprintf(os.Stderr, "The documentation in %s is not up to date, regenerate it by running `make generate-docs`\n", *adoc)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	if err = os.MkdirAll(*adoc, 0755); err != nil {
 		return
 	}
 
-	if err = asciidoc.GenerateAsciidoc(*adoc, rego...); err != nil {
+	if err = asciidoc.Generate(*adoc, opts, rego...); err != nil {
 		return
 	}
 }
+
+// report prints the error to stderr, when the error is a list of problems
+// found in the Rego files or their annotations each one is printed on its own
+// line
+func report(err error) {
+	var problems []error
+	var parseErrs ast.Errors
+	var validationErrs asciidoc.ValidationErrors
+	switch {
+	case errors.As(err, &parseErrs):
+		for _, e := range parseErrs {
+			problems = append(problems, e)
+		}
+	case errors.As(err, &validationErrs):
+		for _, e := range validationErrs {
+			problems = append(problems, e)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "Unable to generate documentation, found %d problem(s) in the Rego files:\n", len(problems))
+	for _, e := range problems {
+		fmt.Fprintf(os.Stderr, "  %v\n", e)
+	}
+}
@@ -6,6 +6,7 @@ require (
 	github.com/conforma/cli v0.7.95
 	github.com/google/addlicense v1.1.1
 	github.com/open-policy-agent/conftest v0.55.0
+	github.com/open-policy-agent/opa v0.70.0
 	github.com/styrainc/regal v0.29.2
 	github.com/tektoncd/cli v0.39.1
 	oras.land/oras v1.2.3
@@ -295,7 +296,6 @@ require (
 	github.com/nsf/termbox-go v1.1.1 // indirect
 	github.com/oklog/ulid v1.3.1 // indirect
 	github.com/olekukonko/tablewriter v0.0.5 // indirect
-	github.com/open-policy-agent/opa v0.70.0 // indirect
 	github.com/opencontainers/go-digest v1.0.0 // indirect
 	github.com/opencontainers/image-spec v1.1.1 // indirect
 	github.com/opentracing/opentracing-go v1.2.0 // indirect
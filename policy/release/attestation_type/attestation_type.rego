@@ -89,6 +89,11 @@ deny contains result if {
 #   - redhat
 #   - redhat_rpms
 #   effective_on: 2023-08-31T00:00:00Z
+#   deprecated:
+#     since: 2026-10-18T00:00:00Z
+#     reason: >-
+#       The Conforma CLI no longer produces the old attestation format, the
+#       check can not fail with the supported versions of the CLI.
 deny contains result if {
 	# Use input.attestations directly so we can detect the actual format in use.
 	some att in input.attestations
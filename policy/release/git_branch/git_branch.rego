@@ -19,7 +19,7 @@ import rego.v1
 #   failure_msg: Build is from a branch %s which is not a trusted branch
 #   collections:
 #   - redhat_rpms
-#   effective_on: 2025-07-01
+#   effective_on: 2025-07-01T00:00:00Z
 deny contains result if {
 	some task in lib.tasks_from_pipelinerun
 
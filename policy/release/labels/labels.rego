@@ -130,6 +130,9 @@ deny contains result if {
 	is_set(_image_labels)
 
 	some err in _required_labels_errors
+
+	# The message of the result is replaced by the message of the error
+	# regal ignore:failure-msg-arguments
 	result := object.union(lib.result_helper(rego.metadata.chain(), []), err)
 }
 
@@ -331,7 +331,7 @@ deny contains result if {
 #     `linux/amd64`). Do not create an image index for the OLM bundle.
 #   collections:
 #   - redhat
-#   effective_on: 2025-5-01T00:00:00Z
+#   effective_on: 2025-05-01T00:00:00Z
 deny contains result if {
 	# Parse manifests from snapshot
 	some csv_manifest in _csv_manifests
@@ -17,20 +17,39 @@
 package main
 
 import (
+	"embed"
 	"errors"
+	"io/fs"
 	"log"
 	"os"
+	"strings"
 
 	// Register custom rego functions
 	_ "github.com/conforma/cli/cmd/validate"
+	"github.com/open-policy-agent/opa/bundle"
+	rbundle "github.com/styrainc/regal/bundle"
 	"github.com/styrainc/regal/cmd"
 )
 
+// The project specific Regal rules enforcing the policy authoring conventions.
+// The `lint` and `test` commands load these from the .regal/rules directory,
+// the language server doesn't, so they're embedded and added to the rules
+// bundled with Regal when running the language server.
+//
+//go:embed .regal/rules
+var customRules embed.FS
+
 func main() {
 	// Remove date and time from any `log.*` calls, as that doesn't add much of value here
 	// Evaluate options for logging later
 	log.SetFlags(0)
 
+	if c, _, err := cmd.RootCommand.Find(os.Args[1:]); err == nil && c.Name() == "language-server" {
+		if err := addCustomRules(); err != nil {
+			log.Fatalf("unable to load the custom Regal rules: %v", err)
+		}
+	}
+
 	if err := cmd.RootCommand.Execute(); err != nil {
 		code := 1
 		if e := (cmd.ExitError{}); errors.As(err, &e) {
@@ -40,3 +59,32 @@ func main() {
 		os.Exit(code)
 	}
 }
+
+// addCustomRules adds the embedded custom rules, without their tests, to the
+// bundle of rules Regal evaluates
+func addCustomRules() error {
+	rules, err := fs.Sub(customRules, ".regal/rules")
+	if err != nil {
+		return err
+	}
+
+	loader, err := bundle.NewFSLoader(rules)
+	if err != nil {
+		return err
+	}
+
+	custom, err := bundle.NewCustomReader(loader.WithFilter(func(_ string, info fs.FileInfo, _ int) bool {
+		return strings.HasSuffix(info.Name(), "_test.rego")
+	})).Read()
+	if err != nil {
+		return err
+	}
+
+	rbundle.LoadedBundle.Modules = append(rbundle.LoadedBundle.Modules, custom.Modules...)
+	// a bundle without declared roots owns all paths, nothing to add then
+	if roots := rbundle.LoadedBundle.Manifest.Roots; roots != nil {
+		*roots = append(*roots, "custom/regal/rules")
+	}
+
+	return nil
+}